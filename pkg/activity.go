@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// botChangeWindow is how long after the bot changed a card the project_card webhook of the change is expected.
+const botChangeWindow = time.Minute
+
+// botChanges remembers the cards the bot just created or moved, to attribute the board activity to the bot or to people.
+type botChanges struct {
+	mu sync.Mutex
+	at map[int64]time.Time
+}
+
+func newBotChanges() *botChanges {
+	return &botChanges{at: make(map[int64]time.Time)}
+}
+
+// add remembers that the bot just changed the card, and forgets the changes older than the window.
+func (c *botChanges) add(cardID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for id, at := range c.at {
+		if now.Sub(at) >= botChangeWindow {
+			delete(c.at, id)
+		}
+	}
+	c.at[cardID] = now
+}
+
+// has returns true if the bot changed the card within the window.
+func (c *botChanges) has(cardID int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.at[cardID]
+	return ok && time.Since(at) < botChangeWindow
+}
+
+// logBotChange logs a card change made by the bot with what it takes to find it in the project's activity,
+// and remembers it to attribute the project_card webhook of the change to the bot.
+func (s *server) logBotChange(ctx context.Context, b *board, card *github.ProjectCard, pr *github.PullRequest, action, column string) {
+	s.botChanges.add(card.GetID())
+	log.Printf("🤖 bot %s card %d of pr #%d %s in column %s (%d) of project %s (%d), delivery %s\n",
+		action, card.GetID(), pr.GetNumber(), pr.GetTitle(), s.cfg.columnTitle(column), b.columns[column].GetID(),
+		b.project.GetName(), b.project.GetID(), deliveryID(ctx))
+}
+
+// handleProjectCard counts the changes to the cards of the boards, telling the bot's changes from the ones made by people.
+func (s *server) handleProjectCard(ctx context.Context, w http.ResponseWriter, e *github.ProjectCardEvent) {
+	action := e.GetAction()
+	if !s.cfg.boardActivity || action != "created" && action != "moved" {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	actor := "human"
+	if s.botChanges.has(e.GetProjectCard().GetID()) || e.GetSender().GetType() == "Bot" {
+		actor = "bot"
+	}
+	boardChanges.inc(action, actor)
+	log.Printf("📋 card %d was %s by %s (%s)\n", e.GetProjectCard().GetID(), action, e.GetSender().GetLogin(), actor)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,20 @@
+package main
+
+// payloadAdapter maps the payload of an incoming webhook to a GitHub webhook payload,
+// for relays in front of the bot that reshape GitHub's payloads.
+type payloadAdapter interface {
+	// adapt returns the GitHub event type and payload standing for the incoming webhook.
+	adapt(eventType string, payload []byte) (string, []byte, error)
+}
+
+// adapters are the payload adapters selectable with WEBHOOK_ADAPTER, by name.
+var adapters = map[string]payloadAdapter{
+	"passthrough": passthroughAdapter{},
+}
+
+// passthroughAdapter leaves GitHub payloads as they are.
+type passthroughAdapter struct{}
+
+func (passthroughAdapter) adapt(eventType string, payload []byte) (string, []byte, error) {
+	return eventType, payload, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/julienschmidt/httprouter"
+)
+
+// requireAdmin only lets requests carrying the admin token through to h.
+func (s *server) requireAdmin(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.adminToken)) != 1 {
+			http.Error(w, "invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		h(w, req, ps)
+	}
+}
+
+// isPaused returns true if card mutations are paused.
+func (s *server) isPaused() bool {
+	return atomic.LoadInt32(&s.paused) == 1
+}
+
+func (s *server) setPaused(paused bool) {
+	var v int32
+	if paused {
+		v = 1
+	}
+	atomic.StoreInt32(&s.paused, v)
+}
+
+func (s *server) pauseHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	s.setPaused(true)
+	log.Println("⏸️ bot paused, webhooks are acknowledged without moving cards")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) resumeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	s.setPaused(false)
+	log.Println("▶️ bot resumed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminRepository returns the repository, named "owner/name", an admin request is about,
+// or the home repository when the request doesn't name one.
+func adminRepository(name string) (*github.Repository, error) {
+	if name == "" {
+		return homeRepository(), nil
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("repo must be a repository like \"%s/%s\", got %q", OWNER, REPO, name)
+	}
+	return &github.Repository{
+		Owner:    &github.User{Login: github.String(parts[0])},
+		Name:     github.String(parts[1]),
+		FullName: github.String(name),
+	}, nil
+}
+
+// moveRequest is the body of a manual card move.
+type moveRequest struct {
+	PR     int    `json:"pr"`
+	Column string `json:"column"`
+	// Repo is the repository of the pull request, named "owner/name", the home repository if empty.
+	Repo string `json:"repo"`
+}
+
+// moveHandler moves the card of a pull request to a column, creating the card if needed,
+// and replies with the resulting card.
+func (s *server) moveHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var body moveRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+	if body.PR <= 0 {
+		http.Error(w, "pr must be a pull request number", http.StatusBadRequest)
+		return
+	}
+	repo, err := adminRepository(body.Repo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	column, ok := s.cfg.findColumn(body.Column)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown column %q, expected one of: %s", body.Column, strings.Join(s.cfg.columns, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	pr, _, err := s.client.PullRequests.Get(ctx, repo.GetOwner().GetLogin(), repo.GetName(), body.PR)
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("getting pr %d of %s", body.PR, repo.GetFullName()), err)
+		return
+	}
+	b, err := s.repoBoard(ctx, repo)
+	if err != nil {
+		s.fail(ctx, w, "getting project board", err)
+		return
+	}
+	card, o, err := s.placeCard(ctx, b, pr, placement{column: column, create: true, manual: true})
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("placing card for pr %s in column %s", pr.GetTitle(), column), err)
+		return
+	}
+	writeJSON(w, s.cfg.statusCode(o), map[string]interface{}{
+		"repo":   repo.GetFullName(),
+		"pr":     body.PR,
+		"column": column,
+		"card":   card,
+	})
+}
+
+// columnsHandler replies with the project columns backing each logical column of the project of the repository
+// in the repo query parameter, or of the home repository, as the bot resolves them, to check it sees the columns
+// operators expect.
+// Looking at the columns must not change the board, so missing columns are left out rather than created.
+func (s *server) columnsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	repo, err := adminRepository(req.URL.Query().Get("repo"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := withoutColumnCreation(context.Background())
+	cfg := s.configFor(ctx, repo)
+	proj, err := primaryProject(ctx, s.client, cfg, repo.GetOwner().GetLogin(), repo.GetName())
+	if err != nil {
+		s.fail(ctx, w, "getting project board", ofRepo(err, repo.GetOwner().GetLogin(), repo.GetName()))
+		return
+	}
+	columns, err := getColumns(ctx, s.client, cfg, proj)
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("getting columns of project %s", proj.GetName()), err)
+		return
+	}
+	type column struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	resolved := make(map[string]column)
+	for logical, c := range columns {
+		resolved[logical] = column{ID: c.GetID(), Name: c.GetName()}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"project": map[string]interface{}{"id": proj.GetID(), "number": proj.GetNumber(), "name": proj.GetName()},
+		"columns": resolved,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("🚨 error writing response: err=%s\n", err)
+	}
+}
+
+// errorsHandler replies with the last reported errors, oldest first.
+func (s *server) errorsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	writeJSON(w, http.StatusOK, s.errors.list())
+}
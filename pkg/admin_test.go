@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Listing the columns doesn't create the missing ones, even with AUTO_CREATE_COLUMNS.
+func TestColumnsHandlerCreatesNoColumns(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{
+		"ADMIN_TOKEN":         "admin",
+		"AUTO_CREATE_COLUMNS": "true",
+		"TRIAGE_COLUMN":       TRIAGE,
+	})
+	f.resetRequests()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/columns", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	rec := httptest.NewRecorder()
+	newHandler(s).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if m := f.mutations(); len(m) != 0 {
+		t.Errorf("got requests %v, want none changing the board", m)
+	}
+	var body struct {
+		Columns map[string]json.RawMessage `json:"columns"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if _, ok := body.Columns[TRIAGE]; ok || body.Columns[IN_REVIEW] == nil {
+		t.Errorf("got columns %v, want %s without the missing %s", body.Columns, IN_REVIEW, TRIAGE)
+	}
+}
+
+// Manual moves apply to the pull request of the repository in the body, OWNER/REPO by default.
+func TestMoveHandlerRepository(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	other := f.addProject("acme/widgets", PROJECT_NAME, "open")
+	review := f.addColumn(other.ID, IN_REVIEW)
+	for _, name := range []string{BACKLOG, IN_PROGRESS, PENDING_RELEASE} {
+		f.addColumn(other.ID, name)
+	}
+	s := newTestServer(t, f, map[string]string{"ADMIN_TOKEN": "admin"})
+	f.pr(7)
+	pr := f.newRepoPR("acme", "widgets", 7)
+	f.addPR(pr)
+
+	for _, tc := range []struct {
+		body   string
+		status int
+	}{
+		{body: `{"pr": 7, "column": "In review", "repo": "acme/widgets"}`, status: http.StatusCreated},
+		{body: `{"pr": 7, "column": "In review", "repo": "acme"}`, status: http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/move", strings.NewReader(tc.body))
+		req.Header.Set("Authorization", "Bearer admin")
+		rec := httptest.NewRecorder()
+		newHandler(s).ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: got status %d, want %d: %s", tc.body, rec.Code, tc.status, rec.Body.String())
+		}
+	}
+
+	if cards := f.cardsIn(review.ID); len(cards) != 1 || cards[0].contentID != pr.GetID() {
+		t.Errorf("got cards %+v in the %s column of acme/widgets, want the card of its pull request", cards, IN_REVIEW)
+	}
+	if cards := f.cardsIn(f.column(IN_REVIEW)); len(cards) != 0 {
+		t.Errorf("got cards %+v in the %s column of %s/%s, want its board left alone", cards, IN_REVIEW, OWNER, REPO)
+	}
+}
+
+// The columns listed are the ones of the project of the repository in the repo query parameter.
+func TestColumnsHandlerRepository(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	other := f.addProject("acme/widgets", PROJECT_NAME, "open")
+	review := f.addColumn(other.ID, IN_REVIEW)
+	for _, name := range []string{BACKLOG, IN_PROGRESS, PENDING_RELEASE} {
+		f.addColumn(other.ID, name)
+	}
+	s := newTestServer(t, f, map[string]string{"ADMIN_TOKEN": "admin"})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/columns?repo=acme/widgets", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	rec := httptest.NewRecorder()
+	newHandler(s).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var body struct {
+		Project struct {
+			ID int64 `json:"id"`
+		} `json:"project"`
+		Columns map[string]struct {
+			ID int64 `json:"id"`
+		} `json:"columns"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Project.ID != other.ID || body.Columns[IN_REVIEW].ID != review.ID {
+		t.Errorf("got project %d and columns %v, want project %d of acme/widgets", body.Project.ID, body.Columns, other.ID)
+	}
+}
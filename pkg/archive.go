@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// maxArchiveInterval is how often cards are archived at most, so that short delays are honored.
+const maxArchiveInterval = 10 * time.Minute
+
+// archiveInterval returns how often the terminal column is checked for cards to archive.
+func archiveInterval(after time.Duration) time.Duration {
+	if after < maxArchiveInterval {
+		return after
+	}
+	return maxArchiveInterval
+}
+
+// archiveCards archives the cards that have been in the terminal column for longer than the archival delay, every interval.
+func (s *server) archiveCards(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		if s.isPaused() {
+			log.Println("⏸️ bot is paused, skipping card archival")
+		} else if err := s.archiveDone(ctx); err != nil {
+			if repo, ok := s.cfg.skipsBoardError(err); ok {
+				s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping card archival of %s\n", err, repo)
+			} else {
+				s.report(ctx, "archiving cards", err)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// archiveDone archives the cards of the terminal column that weren't changed for the archival delay,
+// if their pull request is merged or closed.
+// A card is last changed when it's moved, so its update time is when it entered the column.
+func (s *server) archiveDone(ctx context.Context) error {
+	limits, _, err := s.client.RateLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("get rate limits: %w", err)
+	}
+	if remaining := limits.GetCore().Remaining; remaining < minSweepRateLimit {
+		log.Printf("⚠️ only %d GitHub API requests left until %s, skipping card archival\n", remaining, limits.GetCore().Reset)
+		return nil
+	}
+	boards, err := s.repoBoards(ctx, homeRepository())
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, b := range boards {
+		for _, lane := range b.withSetsOf(s.cfg.archiveColumn) {
+			cards, err := s.listCards(ctx, lane, s.cfg.archiveColumn)
+			if err != nil {
+				return err
+			}
+			for _, card := range cards {
+				if card.GetContentURL() == "" || time.Since(card.GetUpdatedAt().Time) < s.cfg.archiveAfter {
+					continue
+				}
+				// Cards moved to the terminal column by hand may still be open.
+				pr, err := s.pullRequestOf(ctx, card)
+				if err != nil {
+					return err
+				}
+				if pr == nil || !pr.GetMerged() && pr.GetState() != "closed" {
+					continue
+				}
+				archived := true
+				if _, _, err := s.client.Projects.UpdateProjectCard(ctx, card.GetID(), &github.ProjectCardOptions{Archived: &archived}); err != nil {
+					return fmt.Errorf("archive project card %d: %w", card.GetID(), err)
+				}
+				count++
+			}
+		}
+	}
+	if count > 0 {
+		log.Printf("📦 archived %d cards in column %s for longer than %s\n", count, s.cfg.columnTitle(s.cfg.archiveColumn), s.cfg.archiveAfter)
+	}
+	return nil
+}
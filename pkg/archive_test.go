@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Closed pull requests are moved to the terminal column when cards are archived.
+func TestClosedPullRequestMovesToArchiveColumn(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"ARCHIVE_AFTER": "24h"})
+	pr := f.pr(1)
+	f.addCard(f.column(IN_REVIEW), pr)
+	pr.State, pr.Merged = github.String("closed"), github.Bool(true)
+	f.addPR(pr)
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("closed", pr))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if cards := f.cardsIn(f.column(s.cfg.archiveColumn)); len(cards) != 1 {
+		t.Errorf("got %d cards in %s, want 1", len(cards), s.cfg.archiveColumn)
+	}
+}
+
+// Only the cards of merged or closed pull requests that stayed in the terminal column long enough are archived.
+func TestArchiveDone(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"ARCHIVE_AFTER": "24h"})
+	column := f.column(s.cfg.archiveColumn)
+
+	merged := f.newPR(1)
+	merged.State, merged.Merged = github.String("closed"), github.Bool(true)
+	f.addPR(merged)
+	f.age(f.addCard(column, merged), 48*time.Hour)
+	closed := f.newPR(2)
+	closed.State = github.String("closed")
+	f.addPR(closed)
+	f.age(f.addCard(column, closed), 48*time.Hour)
+	open := f.pr(3)
+	f.age(f.addCard(column, open), 48*time.Hour)
+	recent := f.newPR(4)
+	recent.State, recent.Merged = github.String("closed"), github.Bool(true)
+	f.addPR(recent)
+	f.addCard(column, recent)
+
+	if err := s.archiveDone(context.Background()); err != nil {
+		t.Fatalf("archive cards: %v", err)
+	}
+
+	left := make(map[int64]bool)
+	for _, card := range f.cardsIn(column) {
+		left[card.contentID] = true
+	}
+	for _, c := range []struct {
+		pr   *github.PullRequest
+		left bool
+	}{{merged, false}, {closed, false}, {open, true}, {recent, true}} {
+		if left[c.pr.GetID()] != c.left {
+			t.Errorf("pr #%d: got card left %t, want %t", c.pr.GetNumber(), left[c.pr.GetID()], c.left)
+		}
+	}
+}
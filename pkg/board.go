@@ -0,0 +1,784 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// conflictBackoff is how long to wait before the first retry of a conflicting card change.
+const conflictBackoff = 200 * time.Millisecond
+
+// board is a project board along with the columns managed by the bot.
+type board struct {
+	project *github.Project
+	columns map[string]*github.ProjectColumn
+	// sets are the columns of each column set standing in for the default columns, keyed by set name.
+	sets map[string]map[string]*github.ProjectColumn
+	// base is the board with all the column sets this board is a column set of, nil for full boards.
+	base *board
+}
+
+// resolveBoard finds the configured project board of the repository, or of its organization, and its columns.
+func resolveBoard(ctx context.Context, client *github.Client, cfg *config, owner, repo string) (*board, error) {
+	proj, err := primaryProject(ctx, client, cfg, owner, repo)
+	if err != nil {
+		return nil, ofRepo(err, owner, repo)
+	}
+	b, err := newBoard(ctx, client, cfg, proj)
+	return b, ofRepo(err, owner, repo)
+}
+
+// homeRepository returns the repository OWNER/REPO the bot is deployed for.
+// The commands and background jobs, such as polling, archival, stale sweeps and card metrics, only cover its boards:
+// when webhooks of other repositories are sent to the bot too, their boards are only kept up to date by their events.
+func homeRepository() *github.Repository {
+	return &github.Repository{
+		Owner:    &github.User{Login: github.String(OWNER)},
+		Name:     github.String(REPO),
+		FullName: github.String(OWNER + "/" + REPO),
+	}
+}
+
+// repoBoard resolves the board of the repository, with the repository's configuration.
+func (s *server) repoBoard(ctx context.Context, repo *github.Repository) (*board, error) {
+	return resolveBoard(ctx, s.client, s.configFor(ctx, repo), repo.GetOwner().GetLogin(), repo.GetName())
+}
+
+// repoBoards resolves the boards for the events of the repository, with the repository's configuration.
+func (s *server) repoBoards(ctx context.Context, repo *github.Repository) ([]*board, error) {
+	return resolveBoards(ctx, s.client, s.configFor(ctx, repo), repo.GetOwner().GetLogin(), repo.GetName())
+}
+
+// resolveBoards finds the configured project board followed by the extra boards cards are fanned out to.
+func resolveBoards(ctx context.Context, client *github.Client, cfg *config, owner, repo string) ([]*board, error) {
+	boards, err := findBoards(ctx, client, cfg, owner, repo)
+	return boards, ofRepo(err, owner, repo)
+}
+
+// ofRepo records the repository whose project board couldn't be resolved in a missingProjectError.
+func ofRepo(err error, owner, repo string) error {
+	var missing *missingProjectError
+	if errors.As(err, &missing) && missing.repo == "" {
+		missing.repo = owner + "/" + repo
+	}
+	return err
+}
+
+func findBoards(ctx context.Context, client *github.Client, cfg *config, owner, repo string) ([]*board, error) {
+	proj, err := primaryProject(ctx, client, cfg, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	selected := []*github.Project{proj}
+	var projects []*github.Project
+	var scope string
+	if len(cfg.extraProjects) > 0 {
+		if projects, scope, err = listProjects(ctx, client, cfg, owner, repo); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range cfg.extraProjects {
+		extra := findProjectByName(projects, name)
+		if extra == nil {
+			return nil, &missingProjectError{
+				status: http.StatusNotFound,
+				msg:    fmt.Sprintf("project %s not found in %s", name, scope),
+			}
+		}
+		selected = append(selected, extra)
+	}
+	var boards []*board
+	for _, proj := range selected {
+		b, err := newBoard(ctx, client, cfg, proj)
+		if err != nil {
+			return nil, err
+		}
+		boards = append(boards, b)
+	}
+	return boards, nil
+}
+
+// primaryProject returns the configured project board, fetched directly when its ID is configured
+// or else found among the projects in the configured scope.
+func primaryProject(ctx context.Context, client *github.Client, cfg *config, owner, repo string) (*github.Project, error) {
+	if cfg.projectID != "" {
+		return getProjectByID(ctx, client, cfg.projectID)
+	}
+	projects, _, err := listProjects(ctx, client, cfg, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return configuredProject(projects, cfg)
+}
+
+// listProjects returns the project boards in the configured scope, along with a description of the scope.
+func listProjects(ctx context.Context, client *github.Client, cfg *config, owner, repo string) ([]*github.Project, string, error) {
+	scope := fmt.Sprintf("repository %s/%s", owner, repo)
+	if cfg.orgProjects {
+		scope = fmt.Sprintf("organization %s", owner)
+	}
+	opts := &github.ProjectListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var projects []*github.Project
+	for {
+		var page []*github.Project
+		var resp *github.Response
+		var err error
+		if cfg.orgProjects {
+			page, resp, err = client.Organizations.ListProjects(ctx, owner, opts)
+		} else {
+			page, resp, err = client.Repositories.ListProjects(ctx, owner, repo, opts)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("list projects of %s: %w", scope, err)
+		}
+		projects = append(projects, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(projects) == 0 {
+		return nil, "", &missingProjectError{
+			status: http.StatusUnprocessableEntity,
+			msg:    fmt.Sprintf("%s has no project boards", scope),
+		}
+	}
+	return projects, scope, nil
+}
+
+// newBoard returns the project board along with its columns.
+func newBoard(ctx context.Context, client *github.Client, cfg *config, proj *github.Project) (*board, error) {
+	// Cards can't be added to or moved on closed boards.
+	if proj.GetState() == "closed" {
+		return nil, &missingProjectError{
+			status: http.StatusUnprocessableEntity,
+			msg:    fmt.Sprintf("project %s (%d) is closed", proj.GetName(), proj.GetNumber()),
+			closed: true,
+		}
+	}
+	columns, err := getColumns(ctx, client, cfg, proj)
+	if err != nil {
+		return nil, fmt.Errorf("get columns of project %s: %w", proj.GetName(), err)
+	}
+	sets, err := getSetColumns(ctx, client, cfg, proj, columns)
+	if err != nil {
+		return nil, fmt.Errorf("get column sets of project %s: %w", proj.GetName(), err)
+	}
+	return &board{project: proj, columns: columns, sets: sets}, nil
+}
+
+// configuredProject returns the project board selected by the configuration, or a missingProjectError.
+func configuredProject(projects []*github.Project, cfg *config) (*github.Project, error) {
+	proj, err := findProject(projects, cfg)
+	if err != nil {
+		return nil, &missingProjectError{
+			status: http.StatusNotFound,
+			msg:    err.Error(),
+		}
+	}
+	return proj, nil
+}
+
+// findProject returns the project board selected by the configuration.
+// A project matching the configured number is preferred over one matching the name.
+func findProject(projects []*github.Project, cfg *config) (*github.Project, error) {
+	var byName []*github.Project
+	for _, proj := range projects {
+		if cfg.projectNumber != 0 && proj.GetNumber() == cfg.projectNumber {
+			return proj, nil
+		}
+		if proj.GetName() == cfg.projectName {
+			byName = append(byName, proj)
+		}
+	}
+	if len(byName) > 0 {
+		return pickProject(byName, cfg)
+	}
+	if cfg.projectNumber != 0 {
+		return nil, fmt.Errorf("no project with number %d or name %s found", cfg.projectNumber, cfg.projectName)
+	}
+	return nil, fmt.Errorf("project %s not found", cfg.projectName)
+}
+
+// pickProject returns the project board among the ones named after the configured project,
+// according to PROJECT_NAME_CONFLICT when there are several.
+func pickProject(matches []*github.Project, cfg *config) (*github.Project, error) {
+	if len(matches) == 1 || cfg.projectConflict == conflictFirst {
+		return matches[0], nil
+	}
+	if cfg.projectConflict == conflictOpen {
+		var open []*github.Project
+		for _, proj := range matches {
+			if proj.GetState() == "open" {
+				open = append(open, proj)
+			}
+		}
+		if len(open) == 1 {
+			return open[0], nil
+		}
+		if len(open) > 1 {
+			matches = open
+		}
+	}
+	var numbers []string
+	for _, proj := range matches {
+		numbers = append(numbers, fmt.Sprintf("%d (%s)", proj.GetNumber(), proj.GetState()))
+	}
+	return nil, fmt.Errorf("%d projects are named %s, numbers %s, set GH_PROJECT_NUMBER to pick one",
+		len(matches), cfg.projectName, strings.Join(numbers, ", "))
+}
+
+// findProjectByName returns the first project board named name, or nil if there's none.
+func findProjectByName(projects []*github.Project, name string) *github.Project {
+	for _, proj := range projects {
+		if proj.GetName() == name {
+			return proj
+		}
+	}
+	return nil
+}
+
+// getColumns returns the project columns backing each logical column.
+// A logical column is backed by the first project column whose title matches it, in board order:
+// later duplicates, such as a second "Backlog" column, are ignored with a warning so that the
+// bot keeps using the leftmost lane. A project column matching several logical columns is an error.
+// Missing columns are created when auto-creation is enabled, except in contexts without column creation.
+func getColumns(ctx context.Context, client *github.Client, cfg *config, proj *github.Project) (map[string]*github.ProjectColumn, error) {
+	projColumns := make(map[string]*github.ProjectColumn)
+	columns, err := listProjectColumns(ctx, client, proj)
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		name := column.GetName()
+		var matched []string
+		for _, logical := range cfg.columns {
+			if columnMatches(cfg, logical, name) {
+				matched = append(matched, logical)
+			}
+		}
+		if len(matched) > 1 {
+			return nil, fmt.Errorf("column %s is ambiguous, it matches columns %s", name, strings.Join(matched, ", "))
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		if first := projColumns[matched[0]]; first != nil {
+			log.Printf("🤷‍♀️ ignoring duplicate column %s (%d), column %s (%d) backs %s\n",
+				name, column.GetID(), first.GetName(), first.GetID(), matched[0])
+			continue
+		}
+		projColumns[matched[0]] = column
+	}
+	// Missing columns are created in the expected order when configured. Otherwise, missing
+	// optional columns are left out of the returned columns and missing required ones are an error.
+	var prev *github.ProjectColumn
+	for _, logical := range cfg.columns {
+		if projColumns[logical] == nil {
+			switch {
+			case createsColumns(ctx, cfg):
+				column, err := createColumn(ctx, client, proj, cfg.columnTitle(logical), prev)
+				if err != nil {
+					return nil, err
+				}
+				projColumns[logical] = column
+			case contains(cfg.optionalColumns, logical), cfg.autoCreateColumns:
+				continue
+			default:
+				return nil, fmt.Errorf("column %s does not exist", cfg.columnTitle(logical))
+			}
+		}
+		prev = projColumns[logical]
+	}
+	return projColumns, nil
+}
+
+// listProjectColumns returns all the columns of the project, in board order.
+func listProjectColumns(ctx context.Context, client *github.Client, proj *github.Project) ([]*github.ProjectColumn, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var columns []*github.ProjectColumn
+	for {
+		page, resp, err := client.Projects.ListProjectColumns(ctx, proj.GetID(), opts)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, page...)
+		if resp.NextPage == 0 {
+			return columns, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+type noColumnCreationKey struct{}
+
+// withoutColumnCreation returns a context in which missing columns are left out of the board instead of created,
+// even when auto-creation is enabled.
+func withoutColumnCreation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noColumnCreationKey{}, true)
+}
+
+// createsColumns returns true if missing columns are created in the context.
+func createsColumns(ctx context.Context, cfg *config) bool {
+	return cfg.autoCreateColumns && ctx.Value(noColumnCreationKey{}) == nil
+}
+
+// createColumn adds a column named name to the project, right after the column prev or first if prev is nil.
+func createColumn(ctx context.Context, client *github.Client, proj *github.Project, name string, prev *github.ProjectColumn) (*github.ProjectColumn, error) {
+	column, _, err := client.Projects.CreateProjectColumn(ctx, proj.GetID(), &github.ProjectColumnOptions{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("create column %s: %w", name, err)
+	}
+	position := "first"
+	if prev != nil {
+		position = fmt.Sprintf("after:%d", prev.GetID())
+	}
+	if _, err := client.Projects.MoveProjectColumn(ctx, column.GetID(), &github.ProjectColumnMoveOptions{Position: position}); err != nil {
+		return nil, fmt.Errorf("move column %s to %s: %w", name, position, err)
+	}
+	log.Printf("🏗️ created column %s in project %s\n", name, proj.GetName())
+	return column, nil
+}
+
+// columnMatches returns true if the project column title backs the logical column.
+func columnMatches(cfg *config, logical, title string) bool {
+	if re, ok := cfg.columnPatterns[logical]; ok {
+		return re.MatchString(title)
+	}
+	return title == cfg.columnTitle(logical)
+}
+
+// findCard returns the card of the pull request on the board along with the name of its column.
+// The card is nil if the pull request isn't in the scanned columns of the board yet.
+func (s *server) findCard(ctx context.Context, b *board, pr *github.PullRequest) (*github.ProjectCard, string, error) {
+	return s.findCardIn(ctx, b, pr, "not_archived")
+}
+
+// findArchivedCard returns the archived card of the pull request on the board, and the logical column it's in.
+// Archived cards aren't found by findCard, but GitHub still refuses to add the pull request again.
+func (s *server) findArchivedCard(ctx context.Context, b *board, pr *github.PullRequest) (*github.ProjectCard, string, error) {
+	return s.findCardIn(ctx, b, pr, "archived")
+}
+
+// findCardIn looks for the card of the pull request among the cards in the archived state,
+// in the columns of the board and then in the columns of the other column sets.
+// The returned card has the ID of the project column it's in as its column ID.
+func (s *server) findCardIn(ctx context.Context, b *board, pr *github.PullRequest, archivedState string) (*github.ProjectCard, string, error) {
+	scanned := make(map[int64]bool)
+	for _, lane := range b.searchLanes() {
+		for _, columnName := range s.cfg.scanColumns {
+			column := lane.columns[columnName]
+			if column == nil || scanned[column.GetID()] {
+				continue
+			}
+			scanned[column.GetID()] = true
+			cards, err := s.snapshotCards(ctx, lane, columnName, archivedState)
+			if err != nil {
+				return nil, "", err
+			}
+			for _, card := range cards {
+				if isCardOf(card, pr) {
+					// Snapshots are shared, the column is set on a copy.
+					found := *card
+					found.ColumnID = column.ID
+					return &found, columnName, nil
+				}
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// inColumn returns true if the card, found in the logical column current, is in the board's project column
+// for the logical column, rather than in the column of another column set.
+func inColumn(b *board, card *github.ProjectCard, current, column string) bool {
+	return current == column && card.GetColumnID() == b.columns[column].GetID()
+}
+
+// isCardOf returns true if the card stands for the pull request.
+// Cards link to the issue of their pull request, which a pull request converted from an issue
+// shares with it, so the card of the issue is reused rather than adding a duplicate.
+func isCardOf(card *github.ProjectCard, pr *github.PullRequest) bool {
+	if card.GetContentURL() == "" {
+		return isNoteOf(card, pr)
+	}
+	repo, number, ok := parseContentURL(card.GetContentURL())
+	if !ok {
+		return card.GetContentURL() == pr.GetIssueURL()
+	}
+	prRepo, prNumber, ok := parseContentURL(pr.GetIssueURL())
+	if !ok {
+		prRepo, prNumber, ok = parseContentURL(pr.GetURL())
+	}
+	return ok && number == prNumber && strings.EqualFold(repo, prRepo)
+}
+
+// parseContentURL returns the repository, as "owner/name", and the number of the issue or pull request
+// at the API URL, such as https://api.github.com/repos/OWNER/REPO/issues/1 or, on GitHub Enterprise,
+// https://github.example.com/api/v3/repos/OWNER/REPO/pulls/1.
+func parseContentURL(contentURL string) (string, int, bool) {
+	u, err := url.Parse(contentURL)
+	if err != nil {
+		return "", 0, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := len(parts) - 5; i >= 0; i-- {
+		if parts[i] != "repos" || parts[i+3] != "issues" && parts[i+3] != "pulls" {
+			continue
+		}
+		number, err := strconv.Atoi(parts[i+4])
+		if err != nil || number <= 0 {
+			return "", 0, false
+		}
+		return parts[i+1] + "/" + parts[i+2], number, true
+	}
+	return "", 0, false
+}
+
+// unarchiveCard restores the archived card of the pull request, so that it can be moved again.
+func (s *server) unarchiveCard(ctx context.Context, card *github.ProjectCard, pr *github.PullRequest) error {
+	archived := false
+	if _, _, err := s.client.Projects.UpdateProjectCard(ctx, card.GetID(), &github.ProjectCardOptions{Archived: &archived}); err != nil {
+		return fmt.Errorf("unarchive project card for pr %s: %w", pr.GetTitle(), err)
+	}
+	log.Printf("📦 unarchived card for pr %s\n", pr.GetTitle())
+	return nil
+}
+
+// listCards returns all the cards of the logical column on the board that aren't archived.
+func (s *server) listCards(ctx context.Context, b *board, columnName string) ([]*github.ProjectCard, error) {
+	return s.listColumnCards(ctx, b, columnName, "not_archived")
+}
+
+// listColumnCards returns the cards of the logical column on the board in the archived state,
+// one of "all", "archived" or "not_archived".
+func (s *server) listColumnCards(ctx context.Context, b *board, columnName, archivedState string) ([]*github.ProjectCard, error) {
+	opts := &github.ProjectCardListOptions{
+		ArchivedState: &archivedState,
+		ListOptions:   github.ListOptions{PerPage: 100},
+	}
+	var cards []*github.ProjectCard
+	for {
+		page, resp, err := s.client.Projects.ListProjectCards(ctx, b.columns[columnName].GetID(), opts)
+		if err != nil {
+			return nil, fmt.Errorf("list project cards for column %s: %w", columnName, err)
+		}
+		cards = append(cards, page...)
+		if resp.NextPage == 0 {
+			return cards, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// outcome is what placing a card did, ordered from the least to the most change.
+// Webhooks are answered with the status code configured for the outcome.
+type outcome int
+
+const (
+	// skipped means the placement didn't apply to the pull request, 202 Accepted by default.
+	skipped outcome = iota
+	// unchanged means the card already was in the column, 200 OK by default.
+	unchanged
+	// moved means the card was moved to the column, 200 OK by default.
+	moved
+	// created means the card was added to the column, 201 Created by default.
+	created
+)
+
+// placement describes where to put the card of a pull request.
+type placement struct {
+	// column is the logical column to move the card to.
+	column string
+	// create adds the card to the column if the pull request isn't on the board yet.
+	create bool
+	// from, if not empty, only moves cards currently in one of these logical columns.
+	from []string
+	// keep leaves cards already on the board where they are.
+	keep bool
+	// manual placements are requested by a person, and move cards even if they were just changed by hand.
+	manual bool
+	// saveDraft remembers the column cards are moved from, for restoreDraft.
+	saveDraft bool
+	// restoreDraft moves cards back to the column they were moved from with saveDraft, if it's known.
+	restoreDraft bool
+}
+
+// placeCard moves the card of the pull request to the placement's column, and returns the card if there is one.
+// Conflicts with concurrent changes to the board, such as someone moving or adding the card
+// at the same time, are retried after reading the card's current state again.
+func (s *server) placeCard(ctx context.Context, b *board, pr *github.PullRequest, p placement) (*github.ProjectCard, outcome, error) {
+	b = b.inSet(s.cfg.columnSet(pr))
+	key := cardKey(b, pr)
+	if p.restoreDraft {
+		if column, ok := s.drafts.take(key); ok {
+			p.column = column
+		}
+	}
+	// The card was just placed in the column by a previous event, there's no need to look it up.
+	if s.recent.seen(key, p.column) {
+		log.Printf("🤷‍♀️ card for pr %s was just placed in column %s, no change\n", pr.GetTitle(), p.column)
+		return nil, unchanged, nil
+	}
+	for attempt := 0; ; attempt++ {
+		card, o, err := s.tryPlaceCard(ctx, b, pr, p)
+		if err == nil || !isConflict(err) || attempt >= s.cfg.conflictRetries {
+			return card, o, err
+		}
+		log.Printf("🤷‍♀️ conflict placing card for pr %s, retrying: err=%s\n", pr.GetTitle(), err)
+		resetCardSnapshot(ctx)
+		select {
+		case <-ctx.Done():
+			return nil, skipped, ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * conflictBackoff):
+		}
+	}
+}
+
+func (s *server) tryPlaceCard(ctx context.Context, b *board, pr *github.PullRequest, p placement) (*github.ProjectCard, outcome, error) {
+	if b.columns[p.column] == nil {
+		log.Printf("⚠️ optional column %s is not on the board, skipping card for pr %s\n", s.cfg.columnTitle(p.column), pr.GetTitle())
+		return nil, skipped, nil
+	}
+	if !contains(s.cfg.scanColumns, p.column) {
+		log.Printf("⚠️ column %s isn't scanned for existing cards, the card for pr %s may be duplicated\n", s.cfg.columnTitle(p.column), pr.GetTitle())
+	}
+	card, current, err := s.findCard(ctx, b, pr)
+	if err != nil {
+		return nil, skipped, err
+	}
+	if card == nil && !p.create {
+		return nil, skipped, nil
+	}
+	if card != nil && (p.keep || len(p.from) > 0 && !contains(p.from, current)) {
+		return card, skipped, nil
+	}
+	// Moving a card within its column would only disturb the manual ordering.
+	if card != nil && inColumn(b, card, current, p.column) {
+		log.Printf("🤷‍♀️ card for pr %s is already in column %s, no change\n", pr.GetTitle(), p.column)
+		s.recent.record(cardKey(b, pr), p.column)
+		return card, unchanged, nil
+	}
+	if card != nil && !p.manual && s.touches.recentlyChanged(card) {
+		log.Printf("🤷‍♀️ card for pr %s was recently changed by hand, skipping move to column %s\n", pr.GetTitle(), p.column)
+		return card, skipped, nil
+	}
+	if !p.manual {
+		column, err := s.withinLimit(ctx, b, pr, p.column)
+		if err != nil {
+			return nil, skipped, err
+		}
+		if column == "" || card != nil && inColumn(b, card, current, column) {
+			return card, skipped, nil
+		}
+		p.column = column
+	}
+
+	// If the card doesn't exist, create a new card related to the PR in the column.
+	if card == nil {
+		newCard, err := s.createCard(ctx, b, pr, p.column)
+		if err != nil && !isAlreadyOnProject(err) {
+			return nil, skipped, err
+		}
+		if err == nil {
+			s.touches.touch(newCard)
+			s.notify(pr, "", p.column)
+			s.recent.record(cardKey(b, pr), p.column)
+			return newCard, created, nil
+		}
+		// The card may have been archived by hand, bring it back rather than failing on every event.
+		archived, column, findErr := s.findArchivedCard(ctx, b, pr)
+		if findErr != nil {
+			return nil, skipped, findErr
+		}
+		if archived == nil {
+			return nil, skipped, err
+		}
+		if err := s.unarchiveCard(ctx, archived, pr); err != nil {
+			return nil, skipped, err
+		}
+		if inColumn(b, archived, column, p.column) {
+			s.touches.touch(archived)
+			s.notify(pr, "", p.column)
+			s.recent.record(cardKey(b, pr), p.column)
+			return archived, moved, nil
+		}
+		card, current = archived, column
+	}
+
+	// If it does, move the card to the column.
+	if err := s.moveCard(ctx, b, card, pr, p.column); err != nil {
+		return nil, skipped, err
+	}
+	s.touches.touch(card)
+	if p.saveDraft {
+		s.drafts.save(cardKey(b, pr), current)
+	}
+	s.notify(pr, current, p.column)
+	s.recent.record(cardKey(b, pr), p.column)
+	return card, moved, nil
+}
+
+// createCard adds the card of the pull request to the column, at the column's position for new cards.
+func (s *server) createCard(ctx context.Context, b *board, pr *github.PullRequest, column string) (*github.ProjectCard, error) {
+	card, err := s.addCard(ctx, b, pr, column)
+	if err != nil || s.cfg.newCardPositions[column] != "top" {
+		return card, err
+	}
+	// The card is on the board already, failing to reorder it isn't worth failing the placement for.
+	if _, err := s.client.Projects.MoveProjectCard(ctx, card.GetID(), &github.ProjectCardMoveOptions{Position: "top"}); err != nil {
+		log.Printf("⚠️ could not move new card for pr %s to the top of column %s: err=%s\n", pr.GetTitle(), s.cfg.columnTitle(column), err)
+	}
+	return card, nil
+}
+
+// addCard adds a card linked to the pull request at the bottom of the column.
+// When GitHub refuses to link the pull request to the project, a note card is added instead if configured.
+func (s *server) addCard(ctx context.Context, b *board, pr *github.PullRequest, column string) (*github.ProjectCard, error) {
+	columnID := b.columns[column].GetID()
+	card, _, err := s.client.Projects.CreateProjectCard(ctx, columnID, &github.ProjectCardOptions{
+		ContentID:   pr.GetID(),
+		ContentType: "PullRequest",
+	})
+	if err == nil {
+		cardsCreated.inc()
+		s.logBotChange(ctx, b, card, pr, "created", column)
+		return card, nil
+	}
+	if s.cfg.noteTemplate == nil || !isUnlinkable(err) {
+		return nil, fmt.Errorf("create project card for pr %s: %w", pr.GetTitle(), err)
+	}
+	log.Printf("⚠️ pr %s can't be linked to project %s, adding a note card instead: err=%s\n", pr.GetTitle(), b.project.GetName(), err)
+	note, err := renderNote(s.cfg.noteTemplate, pr)
+	if err != nil {
+		return nil, fmt.Errorf("render note card for pr %s: %w", pr.GetTitle(), err)
+	}
+	card, _, err = s.client.Projects.CreateProjectCard(ctx, columnID, &github.ProjectCardOptions{Note: note})
+	if err != nil {
+		return nil, fmt.Errorf("create note card for pr %s: %w", pr.GetTitle(), err)
+	}
+	cardsCreated.inc()
+	s.logBotChange(ctx, b, card, pr, "created note", column)
+	return card, nil
+}
+
+// moveCard moves an existing card of the pull request to the column, at the column's position
+// or according to its priority when priority labels are configured.
+func (s *server) moveCard(ctx context.Context, b *board, card *github.ProjectCard, pr *github.PullRequest, column string) error {
+	position := s.cfg.cardPosition(column)
+	if len(s.cfg.priorityLabels) > 0 {
+		var err error
+		if position, err = s.priorityPosition(ctx, b, card, pr, column); err != nil {
+			return fmt.Errorf("get position of project card for pr %s: %w", pr.GetTitle(), err)
+		}
+	}
+	_, err := s.client.Projects.MoveProjectCard(ctx, card.GetID(), &github.ProjectCardMoveOptions{
+		Position: position,
+		ColumnID: b.columns[column].GetID(),
+	})
+	if err != nil {
+		return fmt.Errorf("move project card for pr %s: %w", pr.GetTitle(), err)
+	}
+	cardsMoved.inc()
+	s.logBotChange(ctx, b, card, pr, "moved", column)
+	return nil
+}
+
+// placeCards places the card of the pull request on each of the boards, and returns the status code
+// of the most significant outcome. Failing boards don't stop the others: if some boards succeeded,
+// the status is 207 Multi-Status along with the errors of the failing boards.
+func (s *server) placeCards(ctx context.Context, boards []*board, pr *github.PullRequest, p placement) (int, error) {
+	var errs multiError
+	best := skipped
+	for _, b := range boards {
+		_, o, err := s.placeCard(ctx, b, pr, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("project %s: %w", b.project.GetName(), err))
+			continue
+		}
+		if o > best {
+			best = o
+		}
+	}
+	switch {
+	case len(errs) == 0:
+		return s.cfg.statusCode(best), nil
+	case len(errs) == 1 && len(boards) == 1:
+		return 0, errors.Unwrap(errs[0])
+	case len(errs) == len(boards):
+		return 0, errs
+	}
+	return http.StatusMultiStatus, errs
+}
+
+// conflictMessages are parts of the messages of the 422 Unprocessable Entity responses to concurrent changes,
+// unlike the other validation errors, which fail the same way when retried.
+var conflictMessages = []string{"conflict", "was modified"}
+
+// isConflict returns true if GitHub rejected a change because the card changed concurrently.
+func isConflict(err error) bool {
+	var ge *github.ErrorResponse
+	if !errors.As(err, &ge) || ge.Response == nil {
+		return false
+	}
+	if ge.Response.StatusCode == http.StatusConflict {
+		return true
+	}
+	if ge.Response.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	messages := []string{ge.Message}
+	for _, e := range ge.Errors {
+		messages = append(messages, e.Message)
+	}
+	for _, msg := range messages {
+		for _, conflict := range conflictMessages {
+			if strings.Contains(strings.ToLower(msg), conflict) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isUnlinkable returns true if GitHub refused to link the content to the project,
+// rather than because it's already on the project.
+func isUnlinkable(err error) bool {
+	var ge *github.ErrorResponse
+	if !errors.As(err, &ge) || ge.Response == nil || ge.Response.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	for _, e := range ge.Errors {
+		if strings.Contains(e.Message, "already") {
+			return false
+		}
+	}
+	return !strings.Contains(ge.Message, "already")
+}
+
+// isAlreadyOnProject returns true if GitHub refused to add the content because it's already on the project.
+func isAlreadyOnProject(err error) bool {
+	var ge *github.ErrorResponse
+	if !errors.As(err, &ge) || ge.Response == nil {
+		return false
+	}
+	return ge.Response.StatusCode == http.StatusUnprocessableEntity && !isUnlinkable(err)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Pull request actions the bot doesn't handle are acknowledged without calling GitHub.
+func TestUnhandledPullRequestActionsAreAccepted(t *testing.T) {
+	for _, action := range []string{"closed", "synchronize", "labeled"} {
+		t.Run(action, func(t *testing.T) {
+			f := newFakeGitHub()
+			defer f.close()
+			s := newTestServer(t, f, nil)
+
+			rec := sendWebhook(t, s, "pull_request", prEvent(action, f.pr(1)))
+
+			if rec.Code != http.StatusAccepted {
+				t.Errorf("%s: got status %d, want %d", action, rec.Code, http.StatusAccepted)
+			}
+			if reqs := f.requests(); len(reqs) > 0 {
+				t.Errorf("%s: got GitHub requests %v, want none", action, reqs)
+			}
+		})
+	}
+}
+
+// Pull requests of other managed repositories are placed on the project of their own repository.
+func TestPlacementUsesPullRequestRepository(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	other := f.addProject("acme/widgets", PROJECT_NAME, "open")
+	review := f.addColumn(other.ID, IN_REVIEW)
+	for _, name := range []string{BACKLOG, IN_PROGRESS, PENDING_RELEASE} {
+		f.addColumn(other.ID, name)
+	}
+	s := newTestServer(t, f, nil)
+	pr := f.newRepoPR("acme", "widgets", 7)
+	f.addPR(pr)
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if cards := f.cardsIn(review.ID); len(cards) != 1 {
+		t.Errorf("got %d cards in the %s column of acme/widgets, want 1", len(cards), IN_REVIEW)
+	}
+	if cards := f.cardsIn(f.column(IN_REVIEW)); len(cards) != 0 {
+		t.Errorf("got %d cards in the %s column of %s/%s, want none", len(cards), IN_REVIEW, OWNER, REPO)
+	}
+}
+
+// PROJECT_NOT_FOUND_REPOS applies to the repository whose project board is missing.
+func TestMissingProjectSkipIsPerRepository(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{
+		"PROJECT_NOT_FOUND":       "error",
+		"PROJECT_NOT_FOUND_REPOS": "acme/widgets=skip",
+	})
+
+	skipped := f.newRepoPR("acme", "widgets", 1)
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", skipped)); rec.Code != http.StatusOK {
+		t.Errorf("acme/widgets: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	failed := f.newRepoPR("acme", "gadgets", 2)
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", failed)); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("acme/gadgets: got status %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	for _, c := range []struct {
+		status  int
+		message string
+		errors  []github.Error
+		want    bool
+	}{
+		{http.StatusConflict, "Conflict", nil, true},
+		{http.StatusUnprocessableEntity, "Validation Failed", []github.Error{{Message: "Project card was modified concurrently"}}, true},
+		{http.StatusUnprocessableEntity, "Conflict moving the card", nil, true},
+		{http.StatusUnprocessableEntity, "Validation Failed", []github.Error{{Message: "Project already has the associated issue"}}, false},
+		{http.StatusUnprocessableEntity, "Validation Failed", []github.Error{{Field: "position", Code: "invalid"}}, false},
+		{http.StatusNotFound, "Not Found", nil, false},
+	} {
+		err := &github.ErrorResponse{Response: &http.Response{StatusCode: c.status}, Message: c.message, Errors: c.errors}
+		if got := isConflict(err); got != c.want {
+			t.Errorf("%d %s %v: got %t, want %t", c.status, c.message, c.errors, got, c.want)
+		}
+	}
+	if isConflict(errors.New("boom")) {
+		t.Error("got a conflict for an error without a response")
+	}
+}
+
+// Validation errors fail the placement right away instead of being retried as conflicts.
+func TestPlaceCardDoesNotRetryValidationErrors(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"CONFLICT_RETRIES": "3"})
+	moves := 0
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/moves") {
+			return false
+		}
+		moves++
+		writeFakeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"message": "Validation Failed",
+			"errors":  []map[string]string{{"resource": "ProjectCard", "field": "position", "code": "invalid"}},
+		})
+		return true
+	})
+	pr := f.pr(1)
+	f.addCard(f.column(IN_PROGRESS), pr)
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if moves != 1 {
+		t.Errorf("got %d move attempts, want 1", moves)
+	}
+}
+
+// Projects and columns are found on any page of the listings.
+func TestBoardListingsPaginated(t *testing.T) {
+	for _, scope := range []string{"repo", "org"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"PROJECT_SCOPE": scope})
+		f.mu.Lock()
+		proj := f.projects[0]
+		if scope == "org" {
+			proj.owner = OWNER
+		}
+		var projects []*fakeProject
+		var columns []*fakeColumn
+		for i := 0; i < 150; i++ {
+			projects = append(projects, &fakeProject{ID: int64(10000 + i), Name: fmt.Sprintf("Project %d", i), State: "open", owner: proj.owner})
+			columns = append(columns, &fakeColumn{ID: int64(20000 + i), Name: fmt.Sprintf("Column %d", i), project: proj.ID})
+		}
+		f.projects = append(projects, f.projects...)
+		f.columns = append(columns, f.columns...)
+		f.mu.Unlock()
+
+		if rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1))); rec.Code != http.StatusCreated {
+			t.Errorf("%s scope: got status %d, want %d: %s", scope, rec.Code, http.StatusCreated, rec.Body.String())
+		}
+		f.close()
+	}
+}
+
+// New cards are moved to the top of their column only when configured.
+func TestNewCardPositions(t *testing.T) {
+	for _, positions := range []string{"", IN_REVIEW + "=top"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"NEW_CARD_POSITIONS": positions})
+		f.addCard(f.column(IN_REVIEW), f.pr(2))
+
+		sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1)))
+		var moves int
+		for _, r := range f.mutations() {
+			if strings.HasSuffix(r.path, "/moves") {
+				moves++
+			}
+		}
+		cards := f.cardsIn(f.column(IN_REVIEW))
+		top := len(cards) == 2 && cards[0].ID == f.cardOf(f.pr(1)).ID
+		if positions == "" && (top || moves != 0) {
+			t.Errorf("got the new card moved to the top with %d moves, want it left at the bottom", moves)
+		}
+		if positions != "" && (!top || moves != 1) {
+			t.Errorf("%s: got the new card left in place with %d moves, want it moved to the top", positions, moves)
+		}
+		f.close()
+	}
+}
+
+// A repository without project boards is refused with a 422 rather than crashing.
+func TestNoProjectBoards(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"PROJECT_NOT_FOUND": "error"})
+	f.mu.Lock()
+	f.projects = nil
+	f.mu.Unlock()
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1)))
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "has no project boards") {
+		t.Errorf("got status %d and body %q, want %d telling there are no project boards", rec.Code, rec.Body.String(), http.StatusUnprocessableEntity)
+	}
+}
+
+// A card already in the target column is left where it is.
+func TestCardAlreadyInColumn(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	f.addCard(f.column(IN_REVIEW), f.pr(1))
+	f.resetRequests()
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if mutations := f.mutations(); len(mutations) != 0 {
+		t.Errorf("got requests %v, want the card left alone", mutations)
+	}
+}
+
+// Missing columns fail the placement, unless they're created in board order with AUTO_CREATE_COLUMNS.
+func TestAutoCreateColumns(t *testing.T) {
+	for _, create := range []string{"false", "true"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"AUTO_CREATE_COLUMNS": create})
+		f.mu.Lock()
+		var kept []*fakeColumn
+		for _, column := range f.columns {
+			if column.Name != IN_REVIEW {
+				kept = append(kept, column)
+			}
+		}
+		f.columns = kept
+		f.mu.Unlock()
+
+		rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1)))
+		if create == "false" {
+			if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "column "+IN_REVIEW+" does not exist") {
+				t.Errorf("got status %d and body %q, want the missing column reported", rec.Code, rec.Body.String())
+			}
+			f.close()
+			continue
+		}
+		if rec.Code != http.StatusCreated || len(f.cardsIn(f.column(IN_REVIEW))) != 1 {
+			t.Errorf("got status %d, want the card created in the new column", rec.Code)
+		}
+		f.mu.Lock()
+		var names []string
+		for _, column := range f.columns {
+			names = append(names, column.Name)
+		}
+		f.mu.Unlock()
+		if strings.Join(names, ",") != strings.Join(allColumns, ",") {
+			t.Errorf("got columns %q, want %q", names, allColumns)
+		}
+		f.close()
+	}
+}
+
+// Of duplicate column names, the leftmost column backs the logical column.
+func TestDuplicateColumnNames(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	first := f.column(IN_REVIEW)
+	duplicate := f.addColumn(f.projects[0].ID, IN_REVIEW)
+
+	columns, err := getColumns(context.Background(), s.client, s.cfg, &github.Project{ID: github.Int64(f.projects[0].ID)})
+	if err != nil {
+		t.Fatalf("get columns: %v", err)
+	}
+	if got := columns[IN_REVIEW].GetID(); got != first {
+		t.Errorf("got column %d backing %s, want the first one %d rather than %d", got, IN_REVIEW, first, duplicate.ID)
+	}
+}
+
+// Moved cards go to the top or bottom of each column as configured, the bottom by default.
+func TestCardPositions(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"CARD_POSITIONS": IN_REVIEW + "=top," + BACKLOG + "=bottom"})
+	b, err := resolveBoard(context.Background(), s.client, s.cfg, OWNER, REPO)
+	if err != nil {
+		t.Fatalf("resolve board: %v", err)
+	}
+	for i, tc := range []struct {
+		column string
+		top    bool
+	}{{IN_REVIEW, true}, {BACKLOG, false}, {PENDING_RELEASE, false}} {
+		f.addCard(f.column(tc.column), f.pr(100+i))
+		pr := f.pr(i + 1)
+		f.addCard(f.column(IN_PROGRESS), pr)
+
+		if _, _, err := s.placeCard(context.Background(), b, pr, placement{column: tc.column}); err != nil {
+			t.Fatalf("%s: place card: %v", tc.column, err)
+		}
+		cards := f.cardsIn(f.column(tc.column))
+		if len(cards) != 2 {
+			t.Fatalf("%s: got cards %v, want 2", tc.column, cards)
+		}
+		if top := cards[0].ID == f.cardOf(pr).ID; top != tc.top {
+			t.Errorf("%s: got the card at the top %v, want %v", tc.column, top, tc.top)
+		}
+	}
+}
+
+// Pull requests opened already merged are filed in the merged column rather than in review.
+func TestOpenedMerged(t *testing.T) {
+	for _, column := range []string{"", BACKLOG} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"MERGED_COLUMN": column})
+		pr := f.newPR(1)
+		pr.Merged = github.Bool(true)
+		f.addPR(pr)
+
+		sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+		want := PENDING_RELEASE
+		if column != "" {
+			want = column
+		}
+		if card := f.cardOf(pr); card == nil || card.column != f.column(want) {
+			t.Errorf("MERGED_COLUMN=%q: got card %v, want it in %s", column, card, want)
+		}
+		f.close()
+	}
+}
+
+func TestParseContentURL(t *testing.T) {
+	testCases := map[string]struct {
+		url    string
+		repo   string
+		number int
+		ok     bool
+	}{
+		"issue":                  {url: "https://api.github.com/repos/acme/widgets/issues/12", repo: "acme/widgets", number: 12, ok: true},
+		"pull request":           {url: "https://api.github.com/repos/acme/widgets/pulls/7", repo: "acme/widgets", number: 7, ok: true},
+		"enterprise":             {url: "https://github.example.com/api/v3/repos/acme/widgets/issues/3", repo: "acme/widgets", number: 3, ok: true},
+		"trailing slash":         {url: "https://api.github.com/repos/acme/widgets/issues/4/", repo: "acme/widgets", number: 4, ok: true},
+		"repository named repos": {url: "https://api.github.com/repos/repos/repos/issues/5", repo: "repos/repos", number: 5, ok: true},
+		"not a number":           {url: "https://api.github.com/repos/acme/widgets/issues/latest"},
+		"not content":            {url: "https://api.github.com/repos/acme/widgets/commits/12"},
+		"empty":                  {url: ""},
+	}
+	for name, tc := range testCases {
+		repo, number, ok := parseContentURL(tc.url)
+		if repo != tc.repo || number != tc.number || ok != tc.ok {
+			t.Errorf("%s: got %q, %d, %v, want %q, %d, %v", name, repo, number, ok, tc.repo, tc.number, tc.ok)
+		}
+	}
+}
+
+// Of several projects named after the board, the one picked depends on PROJECT_NAME_CONFLICT.
+func TestFindProjectNameConflict(t *testing.T) {
+	project := func(number int, state string) *github.Project {
+		return &github.Project{Number: github.Int(number), Name: github.String(PROJECT_NAME), State: github.String(state)}
+	}
+	closedThenOpen := []*github.Project{project(1, "closed"), project(2, "open"), {Number: github.Int(3), Name: github.String("Roadmap")}}
+	bothOpen := []*github.Project{project(1, "open"), project(2, "open")}
+	testCases := map[string]struct {
+		env      map[string]string
+		projects []*github.Project
+		want     int
+		wantErr  string
+	}{
+		"first by default":     {projects: closedThenOpen, want: 1},
+		"open":                 {env: map[string]string{"PROJECT_NAME_CONFLICT": "open"}, projects: closedThenOpen, want: 2},
+		"several open":         {env: map[string]string{"PROJECT_NAME_CONFLICT": "open"}, projects: bothOpen, wantErr: "numbers 1 (open), 2 (open)"},
+		"error":                {env: map[string]string{"PROJECT_NAME_CONFLICT": "error"}, projects: closedThenOpen, wantErr: "2 projects are named Sprint, numbers 1 (closed), 2 (open)"},
+		"number disambiguates": {env: map[string]string{"PROJECT_NAME_CONFLICT": "error", "GH_PROJECT_NUMBER": "2"}, projects: bothOpen, want: 2},
+	}
+	for name, tc := range testCases {
+		cfg := testConfig(t, tc.env)
+		proj, err := findProject(tc.projects, cfg)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: got error %v, want it to contain %q", name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || proj.GetNumber() != tc.want {
+			t.Errorf("%s: got project %d and error %v, want project %d", name, proj.GetNumber(), err, tc.want)
+		}
+	}
+}
+
+// Events against a closed project board fail clearly, or are acknowledged with SKIP_CLOSED_PROJECTS.
+func TestClosedProject(t *testing.T) {
+	for _, skip := range []string{"false", "true"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"SKIP_CLOSED_PROJECTS": skip, "PROJECT_NOT_FOUND": "error"})
+		f.mu.Lock()
+		f.projects[0].State = "closed"
+		f.mu.Unlock()
+
+		rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1)))
+		if skip == "true" && rec.Code != http.StatusOK {
+			t.Errorf("SKIP_CLOSED_PROJECTS=true: got status %d, want %d", rec.Code, http.StatusOK)
+		}
+		if skip == "false" && (rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "is closed")) {
+			t.Errorf("SKIP_CLOSED_PROJECTS=false: got status %d and body %q, want %d telling the project is closed", rec.Code, rec.Body.String(), http.StatusUnprocessableEntity)
+		}
+		for _, r := range f.requests() {
+			if strings.Contains(r.path, "/cards") {
+				t.Errorf("SKIP_CLOSED_PROJECTS=%s: got request %v, want no card requests", skip, r)
+			}
+		}
+		f.close()
+	}
+}
+
+// Moves conflicting with a concurrent change are retried up to CONFLICT_RETRIES times.
+func TestConflictRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		conflicts int
+		status    int
+		moves     int
+		column    string
+	}{
+		{name: "retried", conflicts: 1, status: http.StatusOK, moves: 2, column: IN_REVIEW},
+		{name: "out of retries", conflicts: 3, status: http.StatusConflict, moves: 2, column: IN_PROGRESS},
+	} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"MOVE_ON_REVIEW_REQUESTED": "true", "CONFLICT_RETRIES": "1"})
+		pr := f.pr(1)
+		f.addCard(f.column(IN_PROGRESS), pr)
+		var mu sync.Mutex
+		moves := 0
+		f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+			if !strings.HasSuffix(req.URL.Path, "/moves") {
+				return false
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if moves++; moves > tc.conflicts {
+				return false
+			}
+			writeFakeJSON(w, http.StatusConflict, map[string]string{"message": "Conflict"})
+			return true
+		})
+
+		if rec := sendWebhook(t, s, "pull_request", prEvent("review_requested", pr)); rec.Code != tc.status {
+			t.Errorf("%s: got status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body.String())
+		}
+		mu.Lock()
+		if moves != tc.moves {
+			t.Errorf("%s: got %d move attempts, want %d", tc.name, moves, tc.moves)
+		}
+		mu.Unlock()
+		if card := f.cardOf(pr); card == nil || card.column != f.column(tc.column) {
+			t.Errorf("%s: got card %v, want it in %s", tc.name, card, tc.column)
+		}
+		f.close()
+	}
+}
+
+// Cards archived by hand are brought back and moved rather than failing to create duplicates on every event.
+func TestArchivedCardUnarchived(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	pr := f.pr(1)
+	card := f.addCard(f.column(IN_PROGRESS), pr)
+	card.Archived = true
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	got := f.cardOf(pr)
+	if got == nil || got.ID != card.ID || got.Archived || got.column != f.column(IN_REVIEW) {
+		t.Errorf("got card %+v, want card %d unarchived in %s", got, card.ID, IN_REVIEW)
+	}
+	cards := 0
+	for _, column := range allColumns {
+		cards += len(f.cardsIn(f.column(column)))
+	}
+	if cards != 1 {
+		t.Errorf("got %d cards on the board, want the unarchived card only", cards)
+	}
+}
+
+// Cards linking the issue of a pull request by another API URL, such as on GitHub Enterprise, are reused.
+func TestCardOfContentURL(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	pr, column := f.pr(1), f.column(IN_PROGRESS)
+	f.mu.Lock()
+	f.nextID++
+	card := &fakeCard{
+		ID:         f.nextID,
+		ContentURL: fmt.Sprintf("https://github.example.com/api/v3/repos/%s/%s/issues/%d", strings.ToUpper(OWNER), REPO, pr.GetNumber()),
+		column:     column,
+		contentID:  pr.GetID() + 1000,
+	}
+	f.cards = append(f.cards, card)
+	f.mu.Unlock()
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	cards := f.cardsIn(f.column(IN_REVIEW))
+	if len(cards) != 1 || cards[0].ID != card.ID {
+		t.Errorf("got cards %+v in %s, want card %d moved there", cards, IN_REVIEW, card.ID)
+	}
+	if got := f.cardOf(pr); got != nil {
+		t.Errorf("got a new card %+v for the pr, want the existing card reused", got)
+	}
+}
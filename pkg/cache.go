@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxETagEntries is how many responses are cached at most, the least recently used are evicted first.
+	maxETagEntries = 1000
+	// etagTTL is how long a response is cached, so that the responses of URLs no longer requested are dropped.
+	etagTTL = time.Hour
+)
+
+// etagTransport sends GET requests with the ETag of the last response for the same URL, and replays
+// the cached response when GitHub answers 304 Not Modified, which doesn't count against the rate limit.
+// Projects, columns and cards rarely change between webhooks, so most of their listings are served this way.
+type etagTransport struct {
+	next http.RoundTripper
+
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru are the entries, the most recently used first.
+	lru *list.List
+}
+
+// etagEntry is the last successful response for a URL.
+type etagEntry struct {
+	key     string
+	etag    string
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+func newETagTransport(next http.RoundTripper, size int, ttl time.Duration) *etagTransport {
+	return &etagTransport{
+		next:    next,
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns the unexpired entry of the URL, or nil.
+func (t *etagTransport) get(key string) *etagEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elem, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*etagEntry)
+	if time.Now().After(entry.expires) {
+		t.lru.Remove(elem)
+		delete(t.entries, key)
+		return nil
+	}
+	t.lru.MoveToFront(elem)
+	return entry
+}
+
+// put caches the entry, evicting the least recently used entries over the size.
+func (t *etagTransport) put(entry *etagEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry.expires = time.Now().Add(t.ttl)
+	if elem, ok := t.entries[entry.key]; ok {
+		elem.Value = entry
+		t.lru.MoveToFront(elem)
+		return
+	}
+	t.entries[entry.key] = t.lru.PushFront(entry)
+	for t.lru.Len() > t.size {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*etagEntry).key)
+	}
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry := t.get(key)
+	if entry != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.etag)
+		conditionalRequests.inc()
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		notModifiedResponses.inc()
+		return entry.response(req, resp), nil
+	}
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	t.put(&etagEntry{key: key, etag: etag, header: resp.Header.Clone(), body: body})
+	return resp, nil
+}
+
+// response rebuilds the cached response, with the fresher headers of the 304 response such as the rate limits.
+func (e *etagEntry) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := e.header.Clone()
+	for k, v := range notModified.Header {
+		header[k] = v
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
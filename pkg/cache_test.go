@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// etagServer answers every URL with the same ETag, and 304 Not Modified to conditional requests.
+func etagServer(conditional *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			*conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, req.URL.Path)
+	}))
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+// Cached responses are replayed on 304 Not Modified.
+func TestETagTransportReplays(t *testing.T) {
+	conditional := 0
+	srv := etagServer(&conditional)
+	defer srv.Close()
+	client := &http.Client{Transport: newETagTransport(http.DefaultTransport, 10, time.Hour)}
+
+	get(t, client, srv.URL+"/a")
+	if body := get(t, client, srv.URL+"/a"); body != "/a" || conditional != 1 {
+		t.Errorf("got body %q after %d conditional requests, want the cached body after 1", body, conditional)
+	}
+}
+
+// The least recently used responses are evicted over the size.
+func TestETagTransportEvicts(t *testing.T) {
+	conditional := 0
+	srv := etagServer(&conditional)
+	defer srv.Close()
+	transport := newETagTransport(http.DefaultTransport, 2, time.Hour)
+	client := &http.Client{Transport: transport}
+
+	get(t, client, srv.URL+"/a")
+	get(t, client, srv.URL+"/b")
+	get(t, client, srv.URL+"/a")
+	get(t, client, srv.URL+"/c")
+
+	if len(transport.entries) != 2 || transport.get(srv.URL+"/b") != nil || transport.get(srv.URL+"/a") == nil {
+		t.Errorf("got %d entries, want /a and /c cached and /b evicted", len(transport.entries))
+	}
+}
+
+// Expired responses are fetched again without a condition.
+func TestETagTransportExpires(t *testing.T) {
+	conditional := 0
+	srv := etagServer(&conditional)
+	defer srv.Close()
+	client := &http.Client{Transport: newETagTransport(http.DefaultTransport, 10, -time.Second)}
+
+	get(t, client, srv.URL+"/a")
+	get(t, client, srv.URL+"/a")
+	if conditional != 0 {
+		t.Errorf("got %d conditional requests for an expired response, want none", conditional)
+	}
+}
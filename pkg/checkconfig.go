@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The check-config command validates the configuration and the project boards it resolves to,
+// without serving anything, so that deployment pipelines catch mistakes before they ship:
+//
+//	project-bot check-config
+func init() {
+	subcommands["check-config"] = checkConfig
+}
+
+// checkConfig prints a report of the configuration and resolved boards, and returns an error on any problem.
+// The configuration itself was already loaded and validated. Only the boards of the home repository are checked.
+func checkConfig(cfg *config) error {
+	repo := homeRepository()
+	fmt.Printf("repository: %s\n", repo.GetFullName())
+	if cfg.disableWebhooks {
+		fmt.Println("webhooks: disabled")
+	} else if cfg.webhookSecret == "" {
+		fmt.Println("webhooks: enabled, unsigned")
+	} else {
+		fmt.Println("webhooks: enabled, signed")
+	}
+	if cfg.pollInterval > 0 {
+		fmt.Printf("polling: every %s\n", cfg.pollInterval)
+	}
+	if repoSecret == "" {
+		return errors.New("GITHUB_TOKEN must be set")
+	}
+
+	s := newServer(cfg)
+	if err := checkToken(context.Background(), s.client); err != nil {
+		return err
+	}
+	// Checking the configuration must not change the board, so missing columns are only reported.
+	ctx := withoutColumnCreation(context.Background())
+	cfg = s.configFor(ctx, repo)
+	boards, err := resolveBoards(ctx, s.client, cfg, repo.GetOwner().GetLogin(), repo.GetName())
+	if err != nil {
+		return fmt.Errorf("resolve project boards: %w", explainMoved(explainForbidden(err)))
+	}
+	for _, b := range boards {
+		fmt.Printf("project: %s (%d)\n", b.project.GetName(), b.project.GetID())
+		fmt.Println(strings.Join(columnReport(cfg, b), "\n"))
+	}
+	if len(cfg.issueColumns) > 0 {
+		issueCfg := cfg.forIssues()
+		boards, err := resolveBoards(ctx, s.client, issueCfg, repo.GetOwner().GetLogin(), repo.GetName())
+		if err != nil {
+			return fmt.Errorf("resolve project board of issues: %w", explainMoved(explainForbidden(err)))
+		}
+		for _, b := range boards {
+			fmt.Printf("issues project: %s (%d)\n", b.project.GetName(), b.project.GetID())
+			fmt.Println(strings.Join(columnReport(issueCfg, b), "\n"))
+		}
+	}
+	fmt.Println("configuration ok")
+	return nil
+}
+
+// columnReport returns a line for each logical column of the configuration, with the column backing it on the board.
+func columnReport(cfg *config, b *board) []string {
+	var columns []string
+	for _, name := range cfg.columns {
+		if column := b.columns[name]; column != nil {
+			columns = append(columns, fmt.Sprintf("  %s: %s (%d)", name, column.GetName(), column.GetID()))
+		} else if !contains(cfg.optionalColumns, name) {
+			columns = append(columns, fmt.Sprintf("  %s: missing, created on start", name))
+		} else {
+			columns = append(columns, fmt.Sprintf("  %s: missing, optional", name))
+		}
+	}
+	return columns
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+)
+
+// Checking the configuration leaves the board alone, even when missing columns are created on start.
+func TestCheckConfigDoesNotCreateColumns(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	cfg := testConfig(t, map[string]string{"AUTO_CREATE_COLUMNS": "true", "TRIAGE_COLUMN": "Triage"})
+	cfg.apiURL = f.url()
+	defer func(secret string) { repoSecret = secret }(repoSecret)
+	repoSecret = "testToken"
+
+	if err := checkConfig(cfg); err != nil {
+		t.Fatalf("check config: %v", err)
+	}
+	if mutations := f.mutations(); len(mutations) != 0 {
+		t.Errorf("got requests %v, want the board left unchanged", mutations)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// handleCheckRun moves the cards of the open pull requests whose head commit passed the configured check,
+// such as lint, to the configured column.
+func (s *server) handleCheckRun(ctx context.Context, w http.ResponseWriter, e *github.CheckRunEvent) {
+	run := e.GetCheckRun()
+	if s.cfg.checkRunName == "" || e.GetAction() != "completed" || run.GetName() != s.cfg.checkRunName || run.GetConclusion() != "success" {
+		log.Printf("🤷‍♀️ check run %s %s with conclusion %s is not handled\n", run.GetName(), e.GetAction(), run.GetConclusion())
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	repo := e.GetRepo()
+	prs, err := listHeadPullRequests(ctx, s.client, repo, run.GetHeadSHA())
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("listing pull requests of commit %s", run.GetHeadSHA()), err)
+		return
+	}
+	boards, err := s.repoBoards(ctx, repo)
+	if err != nil {
+		if repo, ok := s.cfg.skipsBoardError(err); ok {
+			s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping events of %s\n", err, repo)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		s.fail(ctx, w, "getting project board", err)
+		return
+	}
+	p := placement{column: s.cfg.checkRunColumn, from: s.cfg.checkRunFrom}
+	best := skipped
+	for _, pr := range prs {
+		if !s.cfg.isTrackedBranch(pr.GetBase().GetRef()) {
+			continue
+		}
+		for _, b := range boards {
+			_, o, err := s.placeCard(ctx, b, pr, p)
+			if err != nil {
+				s.fail(ctx, w, fmt.Sprintf("placing card for pr %s in column %s", pr.GetTitle(), p.column), err)
+				return
+			}
+			if o > best {
+				best = o
+			}
+		}
+	}
+	log.Printf("🟢 check run %s passed on %s for %d pull requests\n", run.GetName(), run.GetHeadSHA(), len(prs))
+	w.WriteHeader(s.cfg.statusCode(best))
+}
+
+// listHeadPullRequests returns the open pull requests of the repository whose head is the commit.
+func listHeadPullRequests(ctx context.Context, client *github.Client, repo *github.Repository, sha string) ([]*github.PullRequest, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var prs []*github.PullRequest
+	for {
+		page, resp, err := client.PullRequests.ListPullRequestsWithCommit(ctx, owner, name, sha, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list pull requests of commit %s in %s/%s: %w", sha, owner, name, err)
+		}
+		// The commit may also be an older commit of other pull requests, which the check didn't run for.
+		for _, pr := range page {
+			if pr.GetState() == "open" && pr.GetHead().GetSHA() == sha {
+				prs = append(prs, pr)
+			}
+		}
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
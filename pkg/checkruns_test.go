@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func checkRunEvent(name, conclusion, sha string, repo *github.Repository) *github.CheckRunEvent {
+	return &github.CheckRunEvent{
+		Action: github.String("completed"),
+		CheckRun: &github.CheckRun{
+			Name:       github.String(name),
+			Conclusion: github.String(conclusion),
+			HeadSHA:    github.String(sha),
+		},
+		Repo:   repo,
+		Sender: &github.User{Login: github.String("octocat")},
+	}
+}
+
+// Only the configured check succeeding on the head commit of a pull request moves its card.
+func TestCheckRun(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{
+		"CHECK_RUN_NAME":   "lint",
+		"CHECK_RUN_COLUMN": IN_REVIEW,
+		"EVENT_TYPES":      "pull_request,check_run",
+	})
+	pr := f.pr(1)
+	f.addCard(f.column(IN_PROGRESS), pr)
+	repo := pr.GetBase().GetRepo()
+
+	for _, e := range []*github.CheckRunEvent{
+		checkRunEvent("test", "success", pr.GetHead().GetSHA(), repo),
+		checkRunEvent("lint", "failure", pr.GetHead().GetSHA(), repo),
+		checkRunEvent("lint", "success", "sha-older", repo),
+	} {
+		if rec := sendWebhook(t, s, "check_run", e); rec.Code >= http.StatusBadRequest {
+			t.Fatalf("check run %s %s: got status %d: %s", e.GetCheckRun().GetName(), e.GetCheckRun().GetConclusion(), rec.Code, rec.Body.String())
+		}
+		if got := f.cardOf(pr).column; got != f.column(IN_PROGRESS) {
+			t.Errorf("check run %s %s on %s: got card moved to column %d, want it left", e.GetCheckRun().GetName(), e.GetCheckRun().GetConclusion(), e.GetCheckRun().GetHeadSHA(), got)
+		}
+	}
+
+	if rec := sendWebhook(t, s, "check_run", checkRunEvent("lint", "success", pr.GetHead().GetSHA(), repo)); rec.Code != http.StatusOK {
+		t.Errorf("lint success: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got := f.cardOf(pr).column; got != f.column(IN_REVIEW) {
+		t.Errorf("lint success: got card in column %d, want it moved to %s", got, IN_REVIEW)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/google/go-github/v29/github"
+	"golang.org/x/oauth2"
+)
+
+// newGitHubClient returns a GitHub client authenticated to perform create/move card actions.
+// The client is safe to share between handlers.
+func newGitHubClient(cfg *config) *github.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.http.dialTimeout,
+			KeepAlive: cfg.http.keepAlive,
+		}).DialContext,
+		TLSHandshakeTimeout:   cfg.http.tlsHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.http.responseHeaderTimeout,
+		MaxIdleConns:          cfg.http.maxIdleConns,
+		MaxIdleConnsPerHost:   cfg.http.maxIdleConns,
+		IdleConnTimeout:       cfg.http.idleConnTimeout,
+	}
+	base := &http.Client{
+		Transport: &tracingTransport{next: &countingTransport{next: newETagTransport(transport, maxETagEntries, etagTTL)}},
+		Timeout:   cfg.http.timeout,
+	}
+
+	// oauth2 picks up the base client from the context.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: repoSecret},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	tc.Timeout = cfg.http.timeout
+	// Redirects mean the repository moved, and are only followed when configured
+	// so that the configuration gets fixed rather than relying on them.
+	tc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !cfg.followRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		log.Printf("⚠️ following redirect from %s to %s, the repository may have moved\n", via[0].URL, req.URL)
+		return nil
+	}
+
+	client := github.NewClient(tc)
+	if cfg.apiURL != nil {
+		client.BaseURL = cfg.apiURL
+	}
+	return client
+}
+
+type callCounterKey struct{}
+
+// withCallCounter returns a context counting the GitHub requests made with it in n.
+func withCallCounter(ctx context.Context, n *int32) context.Context {
+	return context.WithValue(ctx, callCounterKey{}, n)
+}
+
+// countingTransport counts the requests made with a context from withCallCounter.
+type countingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if n, ok := req.Context().Value(callCounterKey{}).(*int32); ok {
+		atomic.AddInt32(n, 1)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// columnSet is a named set of project columns, a swimlane, managing the pull requests it's selected for.
+// Logical columns the set doesn't back with its own project column are shared with the default columns.
+type columnSet struct {
+	name string
+	// titles are the titles of the project columns of the set backing each logical column.
+	titles map[string]string
+	// labels select the set for pull requests with any of these labels.
+	labels []string
+	// branches select the set for pull requests whose head branch starts with any of these prefixes.
+	branches []string
+}
+
+// loadColumnSets reads the column sets listed in COLUMN_SETS from their COLUMN_SET_<NAME>_* variables.
+func loadColumnSets(cfg *config) ([]*columnSet, error) {
+	var sets []*columnSet
+	for _, name := range envList("COLUMN_SETS") {
+		prefix := "COLUMN_SET_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		set := &columnSet{
+			name:     name,
+			titles:   make(map[string]string),
+			labels:   envList(prefix + "_LABELS"),
+			branches: envList(prefix + "_BRANCHES"),
+		}
+		for _, pair := range envList(prefix + "_COLUMNS") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+				return nil, fmt.Errorf("%s_COLUMNS must map columns to titles like \"%s=%s\", got %q", prefix, IN_PROGRESS, "Fixing", pair)
+			}
+			column, ok := cfg.findColumn(strings.TrimSpace(parts[0]))
+			if !ok {
+				return nil, fmt.Errorf("%s_COLUMNS must only contain managed columns, got %q", prefix, parts[0])
+			}
+			set.titles[column] = strings.TrimSpace(parts[1])
+		}
+		if len(set.titles) == 0 {
+			return nil, fmt.Errorf("%s_COLUMNS must be set for column set %s", prefix, name)
+		}
+		if len(set.labels) == 0 && len(set.branches) == 0 {
+			return nil, fmt.Errorf("%s_LABELS or %s_BRANCHES must be set for column set %s", prefix, prefix, name)
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// columnSet returns the name of the column set managing the pull request, or "" for the default columns.
+// Labels take precedence over branches: the first set in COLUMN_SETS with a label of the pull request
+// is selected, then the first set matching its head branch.
+func (cfg *config) columnSet(pr *github.PullRequest) string {
+	for _, set := range cfg.columnSets {
+		for _, label := range pr.Labels {
+			if contains(set.labels, label.GetName()) {
+				return set.name
+			}
+		}
+	}
+	for _, set := range cfg.columnSets {
+		for _, prefix := range set.branches {
+			if strings.HasPrefix(pr.GetHead().GetRef(), prefix) {
+				return set.name
+			}
+		}
+	}
+	return ""
+}
+
+// getSetColumns returns the project columns of each column set, keyed by set name and logical column.
+// Missing columns are created right after the default column they stand in for when configured.
+func getSetColumns(ctx context.Context, client *github.Client, cfg *config, proj *github.Project, defaults map[string]*github.ProjectColumn) (map[string]map[string]*github.ProjectColumn, error) {
+	if len(cfg.columnSets) == 0 {
+		return nil, nil
+	}
+	columns, err := listProjectColumns(ctx, client, proj)
+	if err != nil {
+		return nil, err
+	}
+	sets := make(map[string]map[string]*github.ProjectColumn)
+	for _, set := range cfg.columnSets {
+		sets[set.name] = make(map[string]*github.ProjectColumn)
+		for _, logical := range cfg.columns {
+			title, ok := set.titles[logical]
+			if !ok {
+				continue
+			}
+			for _, column := range columns {
+				if column.GetName() == title {
+					sets[set.name][logical] = column
+					break
+				}
+			}
+			if sets[set.name][logical] != nil {
+				continue
+			}
+			switch {
+			case createsColumns(ctx, cfg):
+				column, err := createColumn(ctx, client, proj, title, defaults[logical])
+				if err != nil {
+					return nil, err
+				}
+				sets[set.name][logical] = column
+			case contains(cfg.optionalColumns, logical), cfg.autoCreateColumns:
+			default:
+				return nil, fmt.Errorf("column %s of column set %s does not exist", title, set.name)
+			}
+		}
+	}
+	return sets, nil
+}
+
+// inSet returns the board as seen by the pull requests of the column set, or the board itself for "".
+func (b *board) inSet(name string) *board {
+	set, ok := b.sets[name]
+	if !ok {
+		return b
+	}
+	columns := make(map[string]*github.ProjectColumn)
+	for logical, column := range b.columns {
+		columns[logical] = column
+	}
+	for logical, column := range set {
+		columns[logical] = column
+	}
+	return &board{project: b.project, columns: columns, base: b}
+}
+
+// searchLanes returns the board followed by the board as seen by each column set, where the card of a pull request
+// may be after its pull request changed set.
+func (b *board) searchLanes() []*board {
+	if b.base == nil {
+		return b.withSets()
+	}
+	return append([]*board{b}, b.base.withSets()...)
+}
+
+// withSets returns the board as seen by each column set, starting with the default columns.
+func (b *board) withSets() []*board {
+	var names []string
+	for name := range b.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	boards := []*board{b}
+	for _, name := range names {
+		boards = append(boards, b.inSet(name))
+	}
+	return boards
+}
+
+// withSetsOf returns the board as seen by each column set with its own column for the logical column,
+// so that listing the cards of the column on each board lists each project column once.
+func (b *board) withSetsOf(column string) []*board {
+	var boards []*board
+	seen := make(map[int64]bool)
+	for _, lane := range b.withSets() {
+		c := lane.columns[column]
+		if c == nil || seen[c.GetID()] {
+			continue
+		}
+		seen[c.GetID()] = true
+		boards = append(boards, lane)
+	}
+	return boards
+}
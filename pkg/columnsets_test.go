@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// The card of a pull request that changed column set is moved to the columns of its new set, not duplicated.
+func TestPlaceCardAfterSetChange(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	fixing := f.addColumn(101, "Fixing")
+	s := newTestServer(t, f, map[string]string{
+		"COLUMN_SETS":             "bugs",
+		"COLUMN_SET_BUGS_COLUMNS": "In review=Fixing",
+		"COLUMN_SET_BUGS_LABELS":  "bug",
+	})
+	pr := f.pr(1)
+	f.addCard(f.column(IN_REVIEW), pr)
+	pr.Labels = []*github.Label{{Name: github.String("bug")}}
+	f.addPR(pr)
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if cards := f.cardsIn(fixing.ID); len(cards) != 1 {
+		t.Errorf("got %d cards in Fixing, want 1", len(cards))
+	}
+	if cards := f.cardsIn(f.column(IN_REVIEW)); len(cards) != 0 {
+		t.Errorf("got %d cards left in %s, want none", len(cards), IN_REVIEW)
+	}
+}
+
+func TestColumnSet(t *testing.T) {
+	cfg := testConfig(t, map[string]string{
+		"COLUMN_SETS":                "bugs,hotfix",
+		"COLUMN_SET_BUGS_COLUMNS":    "In review=Fixing",
+		"COLUMN_SET_BUGS_LABELS":     "bug",
+		"COLUMN_SET_HOTFIX_COLUMNS":  "In review=Hotfix review",
+		"COLUMN_SET_HOTFIX_BRANCHES": "hotfix/",
+	})
+	f := newFakeGitHub()
+	defer f.close()
+	for _, c := range []struct {
+		label, branch, set string
+	}{
+		{"", "feature-1", ""},
+		{"bug", "feature-1", "bugs"},
+		{"", "hotfix/crash", "hotfix"},
+		{"bug", "hotfix/crash", "bugs"},
+	} {
+		pr := f.newPR(1)
+		pr.Head.Ref = github.String(c.branch)
+		if c.label != "" {
+			pr.Labels = []*github.Label{{Name: github.String(c.label)}}
+		}
+		if set := cfg.columnSet(pr); set != c.set {
+			t.Errorf("label %q, branch %q: got set %q, want %q", c.label, c.branch, set, c.set)
+		}
+	}
+}
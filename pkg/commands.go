@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// moveCommand is the prefix of pull request comments asking the bot to move the card, like "/board move In progress".
+const moveCommand = "/board move"
+
+// handleIssueComment runs the board command found in a new pull request comment, and replies with the outcome.
+func (s *server) handleIssueComment(ctx context.Context, w http.ResponseWriter, e *github.IssueCommentEvent) {
+	column, ok := parseMoveCommand(e.GetComment().GetBody())
+	if e.GetAction() != "created" || !e.GetIssue().IsPullRequest() || !ok {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+
+	repo := e.GetRepo()
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	number := e.GetIssue().GetNumber()
+	reply := func(status int, format string, args ...interface{}) {
+		body := fmt.Sprintf(format, args...)
+		if _, _, err := s.client.Issues.CreateComment(ctx, owner, name, number, &github.IssueComment{Body: &body}); err != nil {
+			log.Printf("🚨 error replying to command on pr %d: err=%s\n", number, err)
+		}
+		w.WriteHeader(status)
+	}
+
+	allowed, err := canWrite(ctx, s.client, repo, e.GetComment())
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("checking permissions of %s", e.GetComment().GetUser().GetLogin()), err)
+		return
+	}
+	if !allowed {
+		reply(http.StatusForbidden, "@%s only users with write access can move cards.", e.GetComment().GetUser().GetLogin())
+		return
+	}
+	target, ok := s.cfg.findColumn(column)
+	if !ok {
+		reply(http.StatusUnprocessableEntity, "Unknown column %q, expected one of: %s.", column, strings.Join(s.cfg.columns, ", "))
+		return
+	}
+
+	pr, _, err := s.client.PullRequests.Get(ctx, owner, name, number)
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("getting pr %d", number), err)
+		return
+	}
+	b, err := resolveBoard(ctx, s.client, s.configFor(ctx, repo), owner, name)
+	if err != nil {
+		err = s.report(ctx, "getting project board", err)
+		reply(statusOf(err), "Could not move the card: %s.", err)
+		return
+	}
+	_, o, err := s.placeCard(ctx, b, pr, placement{column: target, create: true, manual: true})
+	if err != nil {
+		err = s.report(ctx, fmt.Sprintf("placing card for pr %s in column %s", pr.GetTitle(), target), err)
+		reply(statusOf(err), "Could not move the card: %s.", err)
+		return
+	}
+	reply(s.cfg.statusCode(o), "Moved the card to **%s**.", target)
+}
+
+// parseMoveCommand returns the column named by a move command on the first line of the comment.
+func parseMoveCommand(body string) (string, bool) {
+	line := strings.TrimSpace(strings.SplitN(body, "\n", 2)[0])
+	if !strings.HasPrefix(line, moveCommand+" ") {
+		return "", false
+	}
+	column := strings.TrimSpace(strings.TrimPrefix(line, moveCommand))
+	return column, column != ""
+}
+
+// canWrite returns true if the author of the comment has write access to the repository.
+func canWrite(ctx context.Context, client *github.Client, repo *github.Repository, comment *github.IssueComment) (bool, error) {
+	// Members and collaborators can have read-only access, so only owners skip the permission check.
+	if comment.GetAuthorAssociation() == "OWNER" {
+		return true, nil
+	}
+	level, _, err := client.Repositories.GetPermissionLevel(ctx, repo.GetOwner().GetLogin(), repo.GetName(), comment.GetUser().GetLogin())
+	if err != nil {
+		return false, fmt.Errorf("get permission level: %w", err)
+	}
+	switch level.GetPermission() {
+	case "admin", "write":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,1068 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// How the project board is picked among several projects with the configured name.
+const (
+	// conflictFirst picks the first project listed.
+	conflictFirst = "first"
+	// conflictOpen picks the only open project, and fails if there isn't exactly one.
+	conflictOpen = "open"
+	// conflictError fails listing the numbers of the projects.
+	conflictError = "error"
+)
+
+// What pushes to a pull request do to its card.
+const (
+	// syncNone leaves the card where it is.
+	syncNone = "none"
+	// syncAdvance moves the card from IN_PROGRESS to IN_REVIEW once the pull request isn't a draft anymore.
+	syncAdvance = "advance"
+	// syncMergeable moves the card back to IN_PROGRESS when the push leaves the pull request with conflicts.
+	syncMergeable = "mergeable"
+)
+
+// config holds the settings of the bot read from the environment.
+type config struct {
+	// webhookSecret verifies the signature of the webhooks.
+	webhookSecret string
+	// allowUnsigned accepts webhooks without verifying their signature when no webhook secret is set.
+	allowUnsigned bool
+	// contentTypeFallback decodes webhooks whose body doesn't match their declared content type as the other one.
+	contentTypeFallback bool
+	// waitForReady refuses webhooks with 503 Service Unavailable until the startup board check succeeded.
+	waitForReady bool
+	// webhookRateLimit is how many webhooks are accepted per minute overall, unlimited if zero.
+	webhookRateLimit int
+	// webhookIPRateLimit is how many webhooks are accepted per minute from each IP address, unlimited if zero.
+	webhookIPRateLimit int
+	// trustedProxies is how many proxies, such as load balancers, add to the X-Forwarded-For header
+	// of the requests in front of the bot, none if zero. It's 1 behind the application load balancer:
+	// without proxies, X-Forwarded-For is whatever the client sends and the peer address is used instead.
+	trustedProxies int
+	// disableWebhooks doesn't serve the webhook endpoint, for deployments relying on polling only.
+	disableWebhooks bool
+	// allowedRepos, if not empty, are the only repositories whose events are processed, as "owner/name".
+	allowedRepos []string
+	// deniedRepos are repositories whose events are ignored, as "owner/name".
+	deniedRepos []string
+	// adapter maps incoming webhook payloads to GitHub ones.
+	adapter payloadAdapter
+	// eventTypes are the webhook event types processed, others are acknowledged without reading them.
+	eventTypes []string
+	// dispatchAction is the event type of the repository dispatches reconciling the board, disabled when empty.
+	dispatchAction string
+	// boardActivity counts the project_card webhooks of the boards, attributed to the bot or to people.
+	boardActivity bool
+	// routingRules place cards by conditions on the webhook payload, ahead of the built-in logic.
+	routingRules []routingRule
+	// issueTransferredAction is what happens to the cards of issues transferred to another repository,
+	// transferArchive or transferDelete, nothing when empty.
+	issueTransferredAction string
+	// issueColumns are the titles of the columns the cards of issues go to when they're opened, reopened or closed,
+	// keyed by action. The cards of issues are only placed for the actions it maps.
+	issueColumns map[string]string
+	// issueProject is the name of the project board the cards of issues are placed on, the one of pull requests if empty.
+	issueProject string
+	// checkRunName is the name of the check run whose success moves the cards of its pull requests, disabled when empty.
+	checkRunName string
+	// checkRunColumn is the logical column the cards move to when the check run succeeds.
+	checkRunColumn string
+	// checkRunFrom are the logical columns cards move from when the check run succeeds.
+	checkRunFrom []string
+	// milestoneClosedTarget is the logical column the cards of open pull requests are moved to when their
+	// milestone is closed, or archiveTarget to archive them. Closed milestones are ignored when it's empty.
+	milestoneClosedTarget string
+	// defaultEventTimeout bounds the handling of each webhook event, never if zero.
+	defaultEventTimeout time.Duration
+	// eventTimeouts override defaultEventTimeout for event types doing more work, keyed by event type.
+	eventTimeouts map[string]time.Duration
+	// maxConcurrency is how many events can be processed or queued at once, unlimited if zero.
+	maxConcurrency int
+	// maxRepoConcurrency is how many events of each repository can be processed or queued at once, unlimited if zero.
+	maxRepoConcurrency int
+	// asyncWorkers process webhook events after acknowledging them, events are processed before answering if zero.
+	asyncWorkers int
+	// asyncQueueSize is how many acknowledged events can wait for a worker before deliveries are refused.
+	asyncQueueSize int
+	// pollInterval is how often the board is reconciled with the open pull requests, never if zero.
+	pollInterval time.Duration
+	// staleInterval is how often cards of stale pull requests in IN_PROGRESS are flagged, never if zero.
+	staleInterval time.Duration
+	// staleAfter is how long a pull request can go without updates before it's stale.
+	staleAfter time.Duration
+	// staleAction is what flagging a stale pull request does, staleBacklog or staleLabel.
+	staleAction string
+	// staleLabel is the label added to stale pull requests with staleLabel.
+	staleLabel string
+	// archiveAfter is how long cards stay in archiveColumn before they're archived, never if zero.
+	// Cards of closed pull requests are moved to archiveColumn when it's set.
+	archiveAfter time.Duration
+	// archiveColumn is the terminal column whose cards are archived after archiveAfter,
+	// RELEASED when deployments are tracked and the column of merged pull requests otherwise.
+	archiveColumn string
+	// projectName is the name of the project board to manage.
+	projectName string
+	// projectNumber is the number of the project board to manage, as shown in its URL.
+	// It takes precedence over projectName when set.
+	projectNumber int
+	// projectID is the REST API ID or the global node ID of the project board to manage.
+	// The project is fetched directly rather than searched for when set, taking precedence over its number and name.
+	projectID string
+	// repoConfig reads the project board and columns of each repository from its repoConfigPath file.
+	repoConfig bool
+	// repoConfigTTL is how long the configuration read from a repository is cached.
+	repoConfigTTL time.Duration
+	// projectConflict picks the project board when several have the configured name, conflictFirst,
+	// conflictOpen or conflictError.
+	projectConflict string
+	// extraProjects are the names of other project boards the cards of pull requests are also placed on.
+	extraProjects []string
+	// orgProjects resolves the project board among the projects of the organization
+	// owning the repository rather than among the repository's own projects.
+	orgProjects bool
+	// skipMissingProject acknowledges events for repositories without the project board
+	// instead of failing them.
+	skipMissingProject bool
+	// missingProjectRepos override skipMissingProject for the repositories, named "owner/name".
+	missingProjectRepos map[string]bool
+	// exitOnInvalidToken exits at startup when GitHub rejects the token, instead of staying unready.
+	exitOnInvalidToken bool
+	// skipClosedProjects acknowledges events when the project board is closed instead of failing them.
+	skipClosedProjects bool
+	// missingProjectLogInterval is how often a repository without the project board is logged, every event if zero.
+	missingProjectLogInterval time.Duration
+	// moveOnReviewRequested moves the card to IN_REVIEW when a reviewer is requested.
+	moveOnReviewRequested bool
+	// moveOnReviewRequestRemoved moves the card back to IN_PROGRESS when no requested reviewers remain.
+	moveOnReviewRequestRemoved bool
+	// moveOnReviewDismissed moves the card back from PENDING_RELEASE to IN_REVIEW when a review is dismissed.
+	moveOnReviewDismissed bool
+	// moveOnDraft moves the card back to IN_PROGRESS when the pull request is converted to a draft,
+	// and back to where it was once it's ready for review.
+	moveOnDraft bool
+	// reviewColumns are the logical columns cards advance to when a review of the state, such as
+	// "commented" or "approved", is submitted. Reviews of other states leave cards where they are.
+	reviewColumns map[string]string
+	// onSynchronize is what pushes to a pull request do to its card, one of the sync* values.
+	onSynchronize string
+	// mergeableRetries is how many times the pull request is fetched again while GitHub computes its mergeable state.
+	mergeableRetries int
+	// mergeableBackoff is how long to wait before fetching the pull request the first time, doubled on each retry.
+	mergeableBackoff time.Duration
+	// activeMilestone is the title of the sprint milestone moving pull requests in progress,
+	// or "*" for any milestone. Milestones are ignored when it's empty.
+	activeMilestone string
+	// releaseEnvironment is the deployment environment whose successful deployments move cards to RELEASED,
+	// disabled when empty.
+	releaseEnvironment string
+	// baseBranches are the base branches of the pull requests on the board, all of them if empty.
+	baseBranches []string
+	// blockedLabel routes pull requests carrying it to BLOCKED, disabled when empty.
+	blockedLabel string
+	// columns are the logical columns managed by the bot, in board order.
+	// They're the required columns plus the optional ones that are configured.
+	columns []string
+	// optionalColumns are the logical columns that may be missing from the board.
+	optionalColumns []string
+	// scanColumns are the logical columns searched for the existing card of a pull request, all of them by default.
+	scanColumns []string
+	// cardPositions are where moved cards go in each logical column, "top" or "bottom".
+	cardPositions map[string]string
+	// newCardPositions are where created cards go in each logical column, "top" or "bottom".
+	// GitHub adds cards at the bottom, cards going to the top are moved there right after being added.
+	newCardPositions map[string]string
+	// priorityLabels orders moved cards by priority, from the label of the highest priority.
+	// Cards are positioned by cardPositions instead when it's empty.
+	priorityLabels []string
+	// columnTitles are the titles of the project columns backing logical columns, when they differ from their names.
+	columnTitles map[string]string
+	// columnPatterns match the titles of the project columns backing each logical column.
+	// Logical columns without a pattern match a column with the exact same name.
+	columnPatterns map[string]*regexp.Regexp
+	// adminToken guards the admin endpoints, which are disabled when it's empty.
+	adminToken string
+	// columnSets are the swimlanes managing the pull requests they're selected for instead of the default columns.
+	columnSets []*columnSet
+	// mergedColumn is the logical column of pull requests that are already merged when they're opened.
+	mergedColumn string
+	// selfTestColumn is the title of the scratch column the admin self-test adds cards to, disabled when empty.
+	selfTestColumn string
+	// paused starts the bot with card mutations paused.
+	paused bool
+	// statusCodes are the status codes webhooks are answered with for each outcome.
+	statusCodes map[outcome]int
+	// dedupWindow is how long placing a card in the column it was just placed in is skipped, never if zero.
+	dedupWindow time.Duration
+	// openedGracePeriod is how long the placement of newly opened pull requests waits for more events, none if zero.
+	openedGracePeriod time.Duration
+	// manualCooldown is how long cards changed by hand are left alone by automated moves, never if zero.
+	manualCooldown time.Duration
+	// conflictRetries is how many times a card change conflicting with a concurrent change is retried.
+	conflictRetries int
+	// autoCreateColumns creates the logical columns missing from the project board instead of failing.
+	autoCreateColumns bool
+	// noteTemplate renders the note cards added when a pull request can't be linked to the project.
+	// Note cards are disabled when it's nil.
+	noteTemplate *template.Template
+	// wipLimits are how many cards each logical column can hold before cards are kept out of it.
+	wipLimits map[string]int
+	// wipLimitAction is the logical column cards go to instead of a full column, or wipComment
+	// to leave them where they are and comment on the pull request.
+	wipLimitAction string
+	// minApprovals is how many reviewers must approve a pull request before its card moves to PENDING_RELEASE.
+	minApprovals int
+	// requireReviewDecision only moves cards to PENDING_RELEASE once the required reviewers of the pull request,
+	// such as its code owners, approve it.
+	requireReviewDecision bool
+	// syncNoteLabels renders the note cards again when the labels of their pull request change.
+	syncNoteLabels bool
+	// notifyURLs are the incoming webhooks of the slack, teams and webhook notifiers, by name.
+	notifyURLs map[string]string
+	// notifiers are told about card changes.
+	notifiers []notifier
+	// notifyTemplate renders the notification messages.
+	notifyTemplate *template.Template
+	// otlpEndpoint receives a trace of each webhook over OTLP/HTTP, traces are disabled when empty.
+	otlpEndpoint string
+	// serviceName is the name of the bot in the traces.
+	serviceName string
+	// debugHeaders adds the number of GitHub calls and the processing time of each webhook to its response headers.
+	debugHeaders bool
+	// enablePprof serves the profiling endpoints under /debug/pprof.
+	enablePprof bool
+	// cardMetricsInterval is how often the cards per column are counted for /metrics.
+	// They're counted on each poll instead when it's zero.
+	cardMetricsInterval time.Duration
+	// errorLogSize is how many of the last errors are kept for the admin endpoint.
+	errorLogSize int
+	// plainLogs starts log messages with plain text instead of emoji.
+	plainLogs bool
+	// http tunes the HTTP client used to talk to GitHub.
+	http httpConfig
+	// followRedirects follows the redirects GitHub answers with for moved repositories.
+	followRedirects bool
+	// apiURL is the base URL of the GitHub API, if different from the public one.
+	apiURL *url.URL
+}
+
+// columnTitle returns the title of the project column backing the logical column.
+func (cfg *config) columnTitle(logical string) string {
+	if title, ok := cfg.columnTitles[logical]; ok {
+		return title
+	}
+	return logical
+}
+
+// columnsBefore returns the logical columns left of the logical column on the board.
+func (cfg *config) columnsBefore(logical string) []string {
+	var before []string
+	for _, column := range cfg.columns {
+		if column == logical {
+			break
+		}
+		before = append(before, column)
+	}
+	return before
+}
+
+// statusCode returns the status code webhooks are answered with for the outcome.
+func (cfg *config) statusCode(o outcome) int {
+	return cfg.statusCodes[o]
+}
+
+// cardPosition returns where cards moved to the logical column go, the bottom unless configured.
+func (cfg *config) cardPosition(logical string) string {
+	if position, ok := cfg.cardPositions[logical]; ok {
+		return position
+	}
+	return "bottom"
+}
+
+// findColumn returns the managed logical column named name, or titled name on the board, ignoring case.
+func (cfg *config) findColumn(name string) (string, bool) {
+	for _, column := range cfg.columns {
+		if strings.EqualFold(column, name) || strings.EqualFold(cfg.columnTitle(column), name) {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// openedColumn returns the logical column of newly opened pull requests.
+// Pull requests from forks go to EXTERNAL and pull requests from outside contributors go to TRIAGE
+// when they're configured.
+func (cfg *config) openedColumn(pr *github.PullRequest) string {
+	if pr.GetMerged() {
+		return cfg.mergedColumn
+	}
+	if contains(cfg.columns, EXTERNAL) && isFork(pr) {
+		return EXTERNAL
+	}
+	if !contains(cfg.columns, TRIAGE) {
+		return IN_REVIEW
+	}
+	switch pr.GetAuthorAssociation() {
+	case "NONE", "FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER":
+		return TRIAGE
+	}
+	return IN_REVIEW
+}
+
+// skipsBoardError returns the repository, named "owner/name", whose project board couldn't be resolved with err,
+// and true if its events are acknowledged because the board is missing or closed.
+func (cfg *config) skipsBoardError(err error) (string, bool) {
+	var missing *missingProjectError
+	if !errors.As(err, &missing) {
+		return "", false
+	}
+	if missing.closed {
+		return missing.repo, cfg.skipClosedProjects
+	}
+	if skip, ok := cfg.missingProjectRepos[strings.ToLower(missing.repo)]; ok {
+		return missing.repo, skip
+	}
+	return missing.repo, cfg.skipMissingProject
+}
+
+// eventTimeout returns how long handling an event of the type may take, unbounded if zero.
+func (cfg *config) eventTimeout(eventType string) time.Duration {
+	if timeout, ok := cfg.eventTimeouts[eventType]; ok {
+		return timeout
+	}
+	return cfg.defaultEventTimeout
+}
+
+// isManagedRepo returns true if the events of the repository, named "owner/name", are processed.
+func (cfg *config) isManagedRepo(fullName string) bool {
+	matches := func(repos []string) bool {
+		for _, repo := range repos {
+			if strings.EqualFold(repo, fullName) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(cfg.allowedRepos) > 0 && !matches(cfg.allowedRepos) {
+		return false
+	}
+	return !matches(cfg.deniedRepos)
+}
+
+// isTrackedBranch returns true if pull requests targeting the base branch belong on the board.
+func (cfg *config) isTrackedBranch(base string) bool {
+	return len(cfg.baseBranches) == 0 || contains(cfg.baseBranches, base)
+}
+
+// isBlocked returns true if the pull request carries the blocked label.
+func (cfg *config) isBlocked(pr *github.PullRequest) bool {
+	if cfg.blockedLabel == "" {
+		return false
+	}
+	for _, label := range pr.Labels {
+		if strings.EqualFold(label.GetName(), cfg.blockedLabel) {
+			return true
+		}
+	}
+	return false
+}
+
+// unblockedColumn returns the logical column of a pull request once it's unblocked, recomputed from its state.
+func (cfg *config) unblockedColumn(pr *github.PullRequest) string {
+	switch {
+	case len(pr.RequestedReviewers) > 0 || len(pr.RequestedTeams) > 0:
+		return IN_REVIEW
+	case cfg.isActiveMilestone(pr.GetMilestone()):
+		return IN_PROGRESS
+	}
+	return cfg.openedColumn(pr)
+}
+
+// isActiveMilestone returns true if the milestone is the active sprint.
+func (cfg *config) isActiveMilestone(m *github.Milestone) bool {
+	if m == nil || cfg.activeMilestone == "" {
+		return false
+	}
+	return cfg.activeMilestone == "*" || m.GetTitle() == cfg.activeMilestone
+}
+
+// httpConfig holds the timeouts and connection pooling limits of the GitHub HTTP client.
+type httpConfig struct {
+	timeout               time.Duration
+	dialTimeout           time.Duration
+	keepAlive             time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	idleConnTimeout       time.Duration
+	maxIdleConns          int
+}
+
+// loadConfig reads the bot's settings from environment variables.
+func loadConfig() (*config, error) {
+	cfg := &config{
+		projectName: PROJECT_NAME,
+	}
+	cfg.webhookSecret = os.Getenv("WEBHOOK_SECRET")
+	allowUnsigned, err := envBool("ALLOW_UNSIGNED", false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.disableWebhooks, err = envBool("DISABLE_WEBHOOKS", false); err != nil {
+		return nil, err
+	}
+	if cfg.contentTypeFallback, err = envBool("CONTENT_TYPE_FALLBACK", false); err != nil {
+		return nil, err
+	}
+	if cfg.waitForReady, err = envBool("WAIT_FOR_READY", false); err != nil {
+		return nil, err
+	}
+	if cfg.webhookRateLimit, err = envInt("WEBHOOK_RATE_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.webhookIPRateLimit, err = envInt("WEBHOOK_IP_RATE_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.webhookRateLimit < 0 || cfg.webhookIPRateLimit < 0 {
+		return nil, fmt.Errorf("WEBHOOK_RATE_LIMIT and WEBHOOK_IP_RATE_LIMIT must be positive, got %d and %d", cfg.webhookRateLimit, cfg.webhookIPRateLimit)
+	}
+	if cfg.trustedProxies, err = envInt("TRUSTED_PROXIES", 0); err != nil {
+		return nil, err
+	}
+	if cfg.trustedProxies < 0 {
+		return nil, fmt.Errorf("TRUSTED_PROXIES must be positive, got %d", cfg.trustedProxies)
+	}
+	adapterName := os.Getenv("WEBHOOK_ADAPTER")
+	if adapterName == "" {
+		adapterName = "passthrough"
+	}
+	if cfg.adapter = adapters[adapterName]; cfg.adapter == nil {
+		return nil, fmt.Errorf("WEBHOOK_ADAPTER %q is not a registered adapter", adapterName)
+	}
+	cfg.allowedRepos = envList("REPO_ALLOWLIST")
+	cfg.deniedRepos = envList("REPO_DENYLIST")
+	cfg.dispatchAction = os.Getenv("DISPATCH_ACTION")
+	cfg.eventTypes = envList("EVENT_TYPES")
+	if len(cfg.eventTypes) == 0 {
+		cfg.eventTypes = []string{"pull_request", "pull_request_review", "issue_comment", "deployment_status"}
+		if cfg.dispatchAction != "" {
+			cfg.eventTypes = append(cfg.eventTypes, "repository_dispatch")
+		}
+		if v, _ := strconv.ParseBool(os.Getenv("BOARD_ACTIVITY")); v {
+			cfg.eventTypes = append(cfg.eventTypes, "project_card")
+		}
+		if os.Getenv("ISSUE_TRANSFERRED_ACTION") != "" || os.Getenv("ISSUE_COLUMNS") != "" {
+			cfg.eventTypes = append(cfg.eventTypes, "issues")
+		}
+		if os.Getenv("CHECK_RUN_NAME") != "" {
+			cfg.eventTypes = append(cfg.eventTypes, "check_run")
+		}
+		if os.Getenv("MILESTONE_CLOSED_TARGET") != "" {
+			cfg.eventTypes = append(cfg.eventTypes, "milestone")
+		}
+	}
+	if cfg.defaultEventTimeout, err = envDuration("EVENT_TIMEOUT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.asyncWorkers, err = envInt("ASYNC_WORKERS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.asyncQueueSize, err = envInt("ASYNC_QUEUE_SIZE", 100); err != nil {
+		return nil, err
+	}
+	if cfg.maxConcurrency, err = envInt("MAX_CONCURRENCY", 0); err != nil {
+		return nil, err
+	}
+	if cfg.maxRepoConcurrency, err = envInt("MAX_REPO_CONCURRENCY", 0); err != nil {
+		return nil, err
+	}
+	if cfg.maxConcurrency < 0 || cfg.maxRepoConcurrency < 0 {
+		return nil, fmt.Errorf("MAX_CONCURRENCY and MAX_REPO_CONCURRENCY must be positive, got %d and %d", cfg.maxConcurrency, cfg.maxRepoConcurrency)
+	}
+	if cfg.asyncWorkers < 0 || cfg.asyncQueueSize < 1 {
+		return nil, fmt.Errorf("ASYNC_WORKERS must be positive and ASYNC_QUEUE_SIZE at least 1, got %d and %d", cfg.asyncWorkers, cfg.asyncQueueSize)
+	}
+	cfg.eventTimeouts = make(map[string]time.Duration)
+	for _, pair := range envList("EVENT_TIMEOUTS") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("EVENT_TIMEOUTS must map event types to durations like \"pull_request=1m\", got %q", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("EVENT_TIMEOUTS must map event types to positive durations, got %q", pair)
+		}
+		cfg.eventTimeouts[strings.TrimSpace(parts[0])] = timeout
+	}
+	if cfg.pollInterval, err = envDuration("POLL_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.staleInterval, err = envDuration("STALE_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.staleAfter, err = envDuration("STALE_AFTER", 14*24*time.Hour); err != nil {
+		return nil, err
+	}
+	switch cfg.staleAction = os.Getenv("STALE_ACTION"); cfg.staleAction {
+	case "":
+		cfg.staleAction = staleBacklog
+	case staleBacklog, staleLabel:
+	default:
+		return nil, fmt.Errorf("STALE_ACTION must be one of backlog or label, got %q", cfg.staleAction)
+	}
+	if cfg.staleLabel = os.Getenv("STALE_LABEL"); cfg.staleLabel == "" {
+		cfg.staleLabel = "stale"
+	}
+	if cfg.archiveAfter, err = envDuration("ARCHIVE_AFTER", 0); err != nil {
+		return nil, err
+	}
+	if cfg.disableWebhooks && cfg.pollInterval == 0 {
+		return nil, errors.New("POLL_INTERVAL must be set when DISABLE_WEBHOOKS is true")
+	}
+	if cfg.webhookSecret == "" && !allowUnsigned && !cfg.disableWebhooks {
+		return nil, errors.New("WEBHOOK_SECRET must be set, or ALLOW_UNSIGNED=true to accept unsigned webhooks for local testing")
+	}
+	cfg.allowUnsigned = allowUnsigned
+	if name := os.Getenv("GH_PROJECT_NAME"); name != "" {
+		cfg.projectName = name
+	}
+	number, err := envInt("GH_PROJECT_NUMBER", 0)
+	if err != nil {
+		return nil, err
+	}
+	if number < 0 {
+		return nil, fmt.Errorf("GH_PROJECT_NUMBER must be a positive number, got %d", number)
+	}
+	cfg.projectNumber = number
+	cfg.projectID = os.Getenv("GH_PROJECT_ID")
+	if cfg.repoConfig, err = envBool("REPO_CONFIG", false); err != nil {
+		return nil, err
+	}
+	if cfg.repoConfigTTL, err = envDuration("REPO_CONFIG_TTL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	switch cfg.projectConflict = os.Getenv("PROJECT_NAME_CONFLICT"); cfg.projectConflict {
+	case "":
+		cfg.projectConflict = conflictFirst
+	case conflictFirst, conflictOpen, conflictError:
+	default:
+		return nil, fmt.Errorf("PROJECT_NAME_CONFLICT must be one of first, open or error, got %q", cfg.projectConflict)
+	}
+	cfg.extraProjects = envList("GH_EXTRA_PROJECTS")
+	switch v := os.Getenv("PROJECT_SCOPE"); v {
+	case "", "repo":
+		cfg.orgProjects = false
+	case "org":
+		cfg.orgProjects = true
+	default:
+		return nil, fmt.Errorf("PROJECT_SCOPE must be one of repo or org, got %q", v)
+	}
+	switch v := os.Getenv("PROJECT_NOT_FOUND"); v {
+	case "", "skip":
+		cfg.skipMissingProject = true
+	case "error":
+		cfg.skipMissingProject = false
+	default:
+		return nil, fmt.Errorf("PROJECT_NOT_FOUND must be one of skip or error, got %q", v)
+	}
+	switch v := os.Getenv("INVALID_TOKEN_ACTION"); v {
+	case "", "unready":
+		cfg.exitOnInvalidToken = false
+	case "exit":
+		cfg.exitOnInvalidToken = true
+	default:
+		return nil, fmt.Errorf("INVALID_TOKEN_ACTION must be one of unready or exit, got %q", v)
+	}
+	cfg.missingProjectRepos = make(map[string]bool)
+	for _, pair := range envList("PROJECT_NOT_FOUND_REPOS") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || (strings.TrimSpace(parts[1]) != "skip" && strings.TrimSpace(parts[1]) != "error") {
+			return nil, fmt.Errorf("PROJECT_NOT_FOUND_REPOS must map repositories to skip or error like \"%s/%s=skip\", got %q", OWNER, REPO, pair)
+		}
+		cfg.missingProjectRepos[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1]) == "skip"
+	}
+	if cfg.skipClosedProjects, err = envBool("SKIP_CLOSED_PROJECTS", false); err != nil {
+		return nil, err
+	}
+	if cfg.missingProjectLogInterval, err = envDuration("PROJECT_NOT_FOUND_LOG_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.moveOnReviewRequested, err = envBool("MOVE_ON_REVIEW_REQUESTED", false); err != nil {
+		return nil, err
+	}
+	if cfg.moveOnReviewRequestRemoved, err = envBool("MOVE_ON_REVIEW_REQUEST_REMOVED", false); err != nil {
+		return nil, err
+	}
+	if cfg.moveOnReviewDismissed, err = envBool("MOVE_ON_REVIEW_DISMISSED", false); err != nil {
+		return nil, err
+	}
+	if cfg.moveOnDraft, err = envBool("MOVE_ON_DRAFT", false); err != nil {
+		return nil, err
+	}
+	moveOnMergeConflict, err := envBool("MOVE_ON_MERGE_CONFLICT", false)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.onSynchronize = os.Getenv("SYNCHRONIZE_ACTION"); cfg.onSynchronize {
+	case "":
+		// MOVE_ON_MERGE_CONFLICT predates SYNCHRONIZE_ACTION.
+		cfg.onSynchronize = syncNone
+		if moveOnMergeConflict {
+			cfg.onSynchronize = syncMergeable
+		}
+	case syncNone, syncAdvance, syncMergeable:
+	default:
+		return nil, fmt.Errorf("SYNCHRONIZE_ACTION must be one of %s, %s or %s, got %q", syncNone, syncAdvance, syncMergeable, cfg.onSynchronize)
+	}
+	if cfg.mergeableRetries, err = envInt("MERGEABLE_RETRIES", 4); err != nil {
+		return nil, err
+	}
+	if cfg.mergeableBackoff, err = envDuration("MERGEABLE_BACKOFF", time.Second); err != nil {
+		return nil, err
+	}
+	cfg.activeMilestone = os.Getenv("ACTIVE_MILESTONE")
+	cfg.columns = append([]string(nil), allColumns...)
+	cfg.columnTitles = make(map[string]string)
+	if title := os.Getenv("TRIAGE_COLUMN"); title != "" {
+		cfg.columns = append([]string{TRIAGE}, cfg.columns...)
+		cfg.columnTitles[TRIAGE] = title
+	}
+	cfg.baseBranches = envList("BASE_BRANCHES")
+	if cfg.blockedLabel = os.Getenv("BLOCKED_LABEL"); cfg.blockedLabel != "" {
+		cfg.columns = append(cfg.columns, BLOCKED)
+		cfg.columnTitles[BLOCKED] = BLOCKED
+		if title := os.Getenv("BLOCKED_COLUMN"); title != "" {
+			cfg.columnTitles[BLOCKED] = title
+		}
+	}
+	if title := os.Getenv("FORK_COLUMN"); title != "" {
+		cfg.columns = append(cfg.columns, EXTERNAL)
+		cfg.columnTitles[EXTERNAL] = title
+	}
+	if title := os.Getenv("FROZEN_COLUMN"); title != "" {
+		cfg.columns = append(cfg.columns, FROZEN)
+		cfg.columnTitles[FROZEN] = title
+	}
+	if cfg.releaseEnvironment = os.Getenv("RELEASE_ENVIRONMENT"); cfg.releaseEnvironment != "" {
+		cfg.columns = append(cfg.columns, RELEASED)
+		cfg.columnTitles[RELEASED] = RELEASED
+		if title := os.Getenv("RELEASED_COLUMN"); title != "" {
+			cfg.columnTitles[RELEASED] = title
+		}
+	}
+	for _, name := range envList("OPTIONAL_COLUMNS") {
+		column, ok := cfg.findColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("OPTIONAL_COLUMNS must only contain managed columns, got %q", name)
+		}
+		cfg.optionalColumns = append(cfg.optionalColumns, column)
+	}
+	for _, name := range envList("SCAN_COLUMNS") {
+		column, ok := cfg.findColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("SCAN_COLUMNS must only contain managed columns, got %q", name)
+		}
+		cfg.scanColumns = append(cfg.scanColumns, column)
+	}
+	if len(cfg.scanColumns) == 0 {
+		cfg.scanColumns = cfg.columns
+	}
+	if cfg.columnSets, err = loadColumnSets(cfg); err != nil {
+		return nil, err
+	}
+	if target := os.Getenv("MILESTONE_CLOSED_TARGET"); target == archiveTarget {
+		cfg.milestoneClosedTarget = target
+	} else if target != "" {
+		column, ok := cfg.findColumn(target)
+		if !ok {
+			return nil, fmt.Errorf("MILESTONE_CLOSED_TARGET must be a managed column or %s, got %q", archiveTarget, target)
+		}
+		cfg.milestoneClosedTarget = column
+	}
+	cfg.mergedColumn = PENDING_RELEASE
+	if name := os.Getenv("MERGED_COLUMN"); name != "" {
+		column, ok := cfg.findColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("MERGED_COLUMN must be a managed column, got %q", name)
+		}
+		cfg.mergedColumn = column
+	}
+	// Merged pull requests end up in RELEASED when their deployments are tracked.
+	cfg.archiveColumn = cfg.mergedColumn
+	if cfg.releaseEnvironment != "" {
+		cfg.archiveColumn = RELEASED
+	}
+	if name := os.Getenv("ARCHIVE_COLUMN"); name != "" {
+		column, ok := cfg.findColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("ARCHIVE_COLUMN must be a managed column, got %q", name)
+		}
+		cfg.archiveColumn = column
+	}
+	cfg.priorityLabels = envList("PRIORITY_LABELS")
+	cfg.reviewColumns = make(map[string]string)
+	for _, pair := range envList("REVIEW_STATE_COLUMNS") {
+		parts := strings.SplitN(pair, "=", 2)
+		state := strings.ToLower(strings.TrimSpace(parts[0]))
+		if state != "commented" && state != "approved" && state != "changes_requested" {
+			return nil, fmt.Errorf("REVIEW_STATE_COLUMNS must only contain the review states commented, approved or changes_requested, got %q", parts[0])
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("REVIEW_STATE_COLUMNS must map review states to columns like \"%s=%s\", got %q", state, IN_REVIEW, pair)
+		}
+		column, ok := cfg.findColumn(strings.TrimSpace(parts[1]))
+		if !ok {
+			return nil, fmt.Errorf("REVIEW_STATE_COLUMNS must only map to managed columns, got %q", parts[1])
+		}
+		cfg.reviewColumns[state] = column
+	}
+	if cfg.boardActivity, err = envBool("BOARD_ACTIVITY", false); err != nil {
+		return nil, err
+	}
+	if cfg.routingRules, err = parseRoutingRules(cfg, os.Getenv("ROUTING_RULES")); err != nil {
+		return nil, fmt.Errorf("ROUTING_RULES: %w", err)
+	}
+	switch cfg.issueTransferredAction = os.Getenv("ISSUE_TRANSFERRED_ACTION"); cfg.issueTransferredAction {
+	case "", transferArchive, transferDelete:
+	default:
+		return nil, fmt.Errorf("ISSUE_TRANSFERRED_ACTION must be one of archive or delete, got %q", cfg.issueTransferredAction)
+	}
+	cfg.issueColumns = make(map[string]string)
+	for _, pair := range envList("ISSUE_COLUMNS") {
+		parts := strings.SplitN(pair, "=", 2)
+		action := strings.ToLower(strings.TrimSpace(parts[0]))
+		if !contains(issueActions, action) {
+			return nil, fmt.Errorf("ISSUE_COLUMNS must only contain the issue actions opened, reopened or closed, got %q", parts[0])
+		}
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("ISSUE_COLUMNS must map issue actions to column titles like \"%s=%s\", got %q", action, BACKLOG, pair)
+		}
+		cfg.issueColumns[action] = strings.TrimSpace(parts[1])
+	}
+	if cfg.issueProject = os.Getenv("ISSUE_PROJECT_NAME"); cfg.issueProject != "" && len(cfg.issueColumns) == 0 {
+		return nil, errors.New("ISSUE_COLUMNS must be set when ISSUE_PROJECT_NAME is set")
+	}
+	if cfg.checkRunName = os.Getenv("CHECK_RUN_NAME"); cfg.checkRunName != "" {
+		column, ok := cfg.findColumn(os.Getenv("CHECK_RUN_COLUMN"))
+		if !ok {
+			return nil, fmt.Errorf("CHECK_RUN_COLUMN must be a managed column when CHECK_RUN_NAME is set, got %q", os.Getenv("CHECK_RUN_COLUMN"))
+		}
+		cfg.checkRunColumn = column
+		for _, name := range envList("CHECK_RUN_FROM") {
+			from, ok := cfg.findColumn(name)
+			if !ok {
+				return nil, fmt.Errorf("CHECK_RUN_FROM must only contain managed columns, got %q", name)
+			}
+			cfg.checkRunFrom = append(cfg.checkRunFrom, from)
+		}
+		if len(cfg.checkRunFrom) == 0 {
+			cfg.checkRunFrom = cfg.columnsBefore(column)
+		}
+	}
+	cfg.wipLimits = make(map[string]int)
+	for _, pair := range envList("WIP_LIMITS") {
+		parts := strings.SplitN(pair, "=", 2)
+		column, ok := cfg.findColumn(strings.TrimSpace(parts[0]))
+		if !ok {
+			return nil, fmt.Errorf("WIP_LIMITS must only contain managed columns, got %q", parts[0])
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(parts[len(parts)-1]))
+		if len(parts) != 2 || err != nil || limit < 1 {
+			return nil, fmt.Errorf("WIP_LIMITS must map columns to positive limits like \"%s=3\", got %q", column, pair)
+		}
+		cfg.wipLimits[column] = limit
+	}
+	if action := os.Getenv("WIP_LIMIT_ACTION"); action == "" || action == wipComment {
+		cfg.wipLimitAction = wipComment
+	} else {
+		column, ok := cfg.findColumn(action)
+		if !ok {
+			return nil, fmt.Errorf("WIP_LIMIT_ACTION must be comment or a managed column, got %q", action)
+		}
+		if _, limited := cfg.wipLimits[column]; limited {
+			return nil, fmt.Errorf("WIP_LIMIT_ACTION must not be a column with a WIP limit, got %q", action)
+		}
+		cfg.wipLimitAction = column
+	}
+	if cfg.minApprovals, err = envInt("MIN_APPROVALS", 1); err != nil {
+		return nil, err
+	}
+	if cfg.minApprovals < 1 {
+		return nil, fmt.Errorf("MIN_APPROVALS must be at least 1, got %d", cfg.minApprovals)
+	}
+	if cfg.requireReviewDecision, err = envBool("REQUIRE_REVIEW_DECISION", false); err != nil {
+		return nil, err
+	}
+	if cfg.cardPositions, err = envPositions(cfg, "CARD_POSITIONS"); err != nil {
+		return nil, err
+	}
+	if cfg.newCardPositions, err = envPositions(cfg, "NEW_CARD_POSITIONS"); err != nil {
+		return nil, err
+	}
+	cfg.columnPatterns = make(map[string]*regexp.Regexp)
+	for column, key := range map[string]string{
+		BACKLOG:         "BACKLOG_COLUMN_PATTERN",
+		IN_PROGRESS:     "IN_PROGRESS_COLUMN_PATTERN",
+		IN_REVIEW:       "IN_REVIEW_COLUMN_PATTERN",
+		PENDING_RELEASE: "PENDING_RELEASE_COLUMN_PATTERN",
+	} {
+		v := os.Getenv(key)
+		if v == "" {
+			continue
+		}
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a regular expression: %w", key, err)
+		}
+		cfg.columnPatterns[column] = re
+	}
+	cfg.adminToken = os.Getenv("ADMIN_TOKEN")
+	cfg.selfTestColumn = os.Getenv("SELFTEST_COLUMN")
+	if cfg.paused, err = envBool("PAUSED", false); err != nil {
+		return nil, err
+	}
+	cfg.statusCodes = make(map[outcome]int)
+	for _, code := range []struct {
+		key     string
+		outcome outcome
+		def     int
+	}{
+		{"STATUS_SKIPPED", skipped, http.StatusAccepted},
+		{"STATUS_UNCHANGED", unchanged, http.StatusOK},
+		{"STATUS_MOVED", moved, http.StatusOK},
+		{"STATUS_CREATED", created, http.StatusCreated},
+	} {
+		status, err := envInt(code.key, code.def)
+		if err != nil {
+			return nil, err
+		}
+		if status < 200 || status > 299 {
+			return nil, fmt.Errorf("%s must be a 2xx status code, got %d", code.key, status)
+		}
+		cfg.statusCodes[code.outcome] = status
+	}
+	if cfg.dedupWindow, err = envDuration("MOVE_DEDUP_WINDOW", 0); err != nil {
+		return nil, err
+	}
+	if cfg.openedGracePeriod, err = envDuration("OPENED_GRACE_PERIOD", 0); err != nil {
+		return nil, err
+	}
+	if cfg.manualCooldown, err = envDuration("MANUAL_MOVE_COOLDOWN", 0); err != nil {
+		return nil, err
+	}
+	if cfg.conflictRetries, err = envInt("CONFLICT_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.autoCreateColumns, err = envBool("AUTO_CREATE_COLUMNS", false); err != nil {
+		return nil, err
+	}
+	if cfg.cardMetricsInterval, err = envDuration("CARD_METRICS_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.errorLogSize, err = envInt("ERROR_LOG_SIZE", 50); err != nil {
+		return nil, err
+	}
+	noteCards, err := envBool("NOTE_CARD_FALLBACK", false)
+	if err != nil {
+		return nil, err
+	}
+	if text := os.Getenv("NOTE_CARD_TEMPLATE"); text != "" || noteCards {
+		if text == "" {
+			text = defaultNoteTemplate
+		}
+		if cfg.noteTemplate, err = template.New("note").Parse(text); err != nil {
+			return nil, fmt.Errorf("NOTE_CARD_TEMPLATE must be a Go template: %w", err)
+		}
+	}
+	if cfg.syncNoteLabels, err = envBool("SYNC_NOTE_LABELS", false); err != nil {
+		return nil, err
+	}
+	if cfg.syncNoteLabels && cfg.noteTemplate == nil {
+		return nil, errors.New("NOTE_CARD_FALLBACK or NOTE_CARD_TEMPLATE must be set when SYNC_NOTE_LABELS is true")
+	}
+	cfg.notifyURLs = make(map[string]string)
+	for name, key := range notifyURLVars {
+		cfg.notifyURLs[name] = os.Getenv(key)
+	}
+	// Without NOTIFIERS, NOTIFY_WEBHOOK_URL gets the notifications in NOTIFY_FORMAT or else they're logged.
+	names := envList("NOTIFIERS")
+	if len(names) == 0 {
+		names = []string{"log"}
+		if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+			format := os.Getenv("NOTIFY_FORMAT")
+			if format == "" {
+				format = "slack"
+			}
+			if _, ok := notifyURLVars[format]; !ok {
+				return nil, fmt.Errorf("NOTIFY_FORMAT must be one of slack, teams or webhook, got %q", format)
+			}
+			names = []string{format}
+			cfg.notifyURLs[format] = url
+		}
+	}
+	for _, name := range names {
+		newNotifier, ok := notifiers[name]
+		if !ok {
+			return nil, fmt.Errorf("NOTIFIERS must only contain log, slack, teams or webhook, got %q", name)
+		}
+		n, err := newNotifier(cfg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.notifiers = append(cfg.notifiers, n)
+	}
+	notifyTemplate := os.Getenv("NOTIFY_TEMPLATE")
+	if notifyTemplate == "" {
+		notifyTemplate = defaultNotifyTemplate
+	}
+	if cfg.notifyTemplate, err = parseNotifyTemplate(notifyTemplate); err != nil {
+		return nil, fmt.Errorf("NOTIFY_TEMPLATE must be a Go template: %w", err)
+	}
+	// The endpoints follow the OpenTelemetry exporter conventions.
+	if cfg.otlpEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); cfg.otlpEndpoint == "" {
+		if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+			cfg.otlpEndpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+		}
+	}
+	if cfg.serviceName = os.Getenv("OTEL_SERVICE_NAME"); cfg.serviceName == "" {
+		cfg.serviceName = "project-bot"
+	}
+	if cfg.debugHeaders, err = envBool("DEBUG_HEADERS", false); err != nil {
+		return nil, err
+	}
+	if cfg.enablePprof, err = envBool("ENABLE_PPROF", false); err != nil {
+		return nil, err
+	}
+	switch style := os.Getenv("LOG_STYLE"); style {
+	case "", "emoji":
+	case "plain":
+		cfg.plainLogs = true
+	default:
+		return nil, fmt.Errorf("LOG_STYLE must be emoji or plain, got %s", style)
+	}
+	if cfg.http, err = loadHTTPConfig(); err != nil {
+		return nil, err
+	}
+	if cfg.followRedirects, err = envBool("FOLLOW_REPO_REDIRECTS", false); err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("GITHUB_API_URL"); v != "" {
+		u, err := url.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("GITHUB_API_URL must be a URL: %w", err)
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		cfg.apiURL = u
+	}
+	return cfg, nil
+}
+
+func loadHTTPConfig() (httpConfig, error) {
+	var cfg httpConfig
+	durations := []struct {
+		key string
+		def time.Duration
+		dst *time.Duration
+	}{
+		{"GH_HTTP_TIMEOUT", 30 * time.Second, &cfg.timeout},
+		{"GH_HTTP_DIAL_TIMEOUT", 5 * time.Second, &cfg.dialTimeout},
+		{"GH_HTTP_KEEP_ALIVE", 30 * time.Second, &cfg.keepAlive},
+		{"GH_HTTP_TLS_HANDSHAKE_TIMEOUT", 5 * time.Second, &cfg.tlsHandshakeTimeout},
+		{"GH_HTTP_RESPONSE_HEADER_TIMEOUT", 10 * time.Second, &cfg.responseHeaderTimeout},
+		{"GH_HTTP_IDLE_CONN_TIMEOUT", 90 * time.Second, &cfg.idleConnTimeout},
+	}
+	for _, d := range durations {
+		v, err := envDuration(d.key, d.def)
+		if err != nil {
+			return cfg, err
+		}
+		*d.dst = v
+	}
+	n, err := envInt("GH_HTTP_MAX_IDLE_CONNS", 10)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.maxIdleConns = n
+	return cfg, nil
+}
+
+// envInt returns the integer value of the environment variable key, or def if it's not set.
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", key, v)
+	}
+	return n, nil
+}
+
+// envBool returns the boolean value of the environment variable key, or def if it's not set.
+func envBool(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", key, v)
+	}
+	return b, nil
+}
+
+// envDuration returns the duration value of the environment variable key, such as "10s", or def if it's not set.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a duration like 10s, got %q", key, v)
+	}
+	return d, nil
+}
+
+// envPositions reads the environment variable key mapping managed columns to "top" or "bottom".
+func envPositions(cfg *config, key string) (map[string]string, error) {
+	positions := make(map[string]string)
+	for _, pair := range envList(key) {
+		parts := strings.SplitN(pair, "=", 2)
+		column, ok := cfg.findColumn(strings.TrimSpace(parts[0]))
+		if !ok {
+			return nil, fmt.Errorf("%s must only contain managed columns, got %q", key, parts[0])
+		}
+		if len(parts) != 2 || (strings.TrimSpace(parts[1]) != "top" && strings.TrimSpace(parts[1]) != "bottom") {
+			return nil, fmt.Errorf("%s must map columns to top or bottom like \"%s=top\", got %q", key, column, pair)
+		}
+		positions[column] = strings.TrimSpace(parts[1])
+	}
+	return positions, nil
+}
+
+// envList returns the comma separated values of the environment variable key.
+func envList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
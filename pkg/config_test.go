@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEnvPositions(t *testing.T) {
+	testCases := map[string]struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		"unset": {
+			want: map[string]string{},
+		},
+		"columns by title or name": {
+			value: "in review = top, " + IN_PROGRESS + "=bottom",
+			want:  map[string]string{IN_REVIEW: "top", IN_PROGRESS: "bottom"},
+		},
+		"unmanaged column": {
+			value:   "Icebox=top",
+			wantErr: true,
+		},
+		"unknown position": {
+			value:   IN_REVIEW + "=middle",
+			wantErr: true,
+		},
+		"missing position": {
+			value:   IN_REVIEW,
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		_, err := loadTestConfig(map[string]string{"CARD_POSITIONS": tc.value})
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: got no error", name)
+			}
+			continue
+		}
+		cfg := testConfig(t, map[string]string{"CARD_POSITIONS": tc.value})
+		if len(cfg.cardPositions) != len(tc.want) {
+			t.Errorf("%s: got positions %v, want %v", name, cfg.cardPositions, tc.want)
+		}
+		for column, position := range tc.want {
+			if cfg.cardPositions[column] != position {
+				t.Errorf("%s: got positions %v, want %v", name, cfg.cardPositions, tc.want)
+			}
+		}
+	}
+}
+
+// Lists skip blank values and surrounding spaces.
+func TestEnvList(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"BASE_BRANCHES": " main, ,release ,"})
+	if got := cfg.baseBranches; len(got) != 2 || got[0] != "main" || got[1] != "release" {
+		t.Errorf("got base branches %q, want [main release]", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// touchSlack is how much later than the bot's own change a card can be updated and still count as changed by the bot,
+// to allow for the delay and clock skew between the bot and GitHub.
+const touchSlack = 10 * time.Second
+
+// cardTouches remembers when the bot last changed each card, to tell the bot's changes from the ones made by hand.
+// Cards changed by hand within the cool-down are left alone so that the bot doesn't fight people moving them.
+type cardTouches struct {
+	cooldown time.Duration
+
+	mu sync.Mutex
+	at map[int64]time.Time
+}
+
+func newCardTouches(cooldown time.Duration) *cardTouches {
+	return &cardTouches{cooldown: cooldown, at: make(map[int64]time.Time)}
+}
+
+// recentlyChanged returns true if the card was last updated within the cool-down by someone other than the bot.
+func (t *cardTouches) recentlyChanged(card *github.ProjectCard) bool {
+	if t.cooldown <= 0 {
+		return false
+	}
+	updated := card.GetUpdatedAt().Time
+	if time.Since(updated) >= t.cooldown {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	touched, ok := t.at[card.GetID()]
+	return !ok || updated.After(touched.Add(touchSlack))
+}
+
+// touch remembers that the bot just changed the card, and forgets changes older than the cool-down.
+func (t *cardTouches) touch(card *github.ProjectCard) {
+	if t.cooldown <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for id, at := range t.at {
+		if now.Sub(at) >= t.cooldown {
+			delete(t.at, id)
+		}
+	}
+	t.at[card.GetID()] = now
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// pendingRetryInterval is how long a placement past its grace period waits for a processing slot.
+const pendingRetryInterval = time.Second
+
+// openedPlacements hold back the placement of newly opened pull requests for a grace period,
+// so that a pull request edited or labeled right after being opened is placed once, where its last event puts it.
+type openedPlacements struct {
+	grace time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingPlacement
+}
+
+// pendingPlacement is the latest placement of a pull request in its grace period.
+type pendingPlacement struct {
+	timer *time.Timer
+	pr    *github.PullRequest
+	p     placement
+}
+
+func newOpenedPlacements(grace time.Duration) *openedPlacements {
+	return &openedPlacements{grace: grace, pending: make(map[string]*pendingPlacement)}
+}
+
+// prKey identifies a pull request across repositories.
+func prKey(pr *github.PullRequest) string {
+	return fmt.Sprintf("%s#%d", strings.ToLower(pr.GetBase().GetRepo().GetFullName()), pr.GetNumber())
+}
+
+// debounce holds back the placement if the pull request was just opened or is still in its grace period,
+// and returns true if it did. The placement supersedes the pending one, and restarts the grace period.
+func (s *server) debounce(ctx context.Context, action string, pr *github.PullRequest, p placement) bool {
+	o := s.opened
+	if o.grace <= 0 {
+		return false
+	}
+	key := prKey(pr)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	pending, ok := o.pending[key]
+	if !ok && action != "opened" {
+		return false
+	}
+	if ok {
+		pending.timer.Stop()
+		// The card of the opened pull request is still to be created.
+		p.create = p.create || pending.p.create
+		log.Printf("🤷‍♀️ pr %s changed in its grace period, placing it in column %s instead of %s\n", pr.GetTitle(), p.column, pending.p.column)
+	}
+	pending = &pendingPlacement{pr: pr, p: p}
+	delivery := deliveryID(ctx)
+	pending.timer = time.AfterFunc(o.grace, func() {
+		o.mu.Lock()
+		if o.pending[key] != pending {
+			o.mu.Unlock()
+			return
+		}
+		delete(o.pending, key)
+		o.mu.Unlock()
+		s.applyPending(delivery, pending)
+	})
+	o.pending[key] = pending
+	return true
+}
+
+// applyPending processes the placement of the pull request once its grace period is over like a received event:
+// in order with the other events of the pull request, within the processing slots, and not while paused.
+func (s *server) applyPending(delivery string, pending *pendingPlacement) {
+	if s.isPaused() {
+		log.Printf("⏸️ bot is paused, dropping the held back placement of pr %s\n", pending.pr.GetTitle())
+		return
+	}
+	ev := queuedEvent{
+		deliveryID: delivery,
+		eventType:  "pull_request",
+		repo:       pending.pr.GetBase().GetRepo().GetFullName(),
+		event:      pending,
+	}
+	// The delivery was already answered, so the placement waits for a slot rather than being refused.
+	if !s.slots.acquire(ev.repo) {
+		time.AfterFunc(pendingRetryInterval, func() { s.applyPending(delivery, pending) })
+		return
+	}
+	ev.turn = s.sequencer.reserve(prKey(pending.pr))
+	s.sequencer.run(ev.turn, func() {
+		defer s.slots.release(ev.repo)
+		// The outcome only shows up in the logs and metrics.
+		s.process(&discardResponseWriter{header: make(http.Header)}, ev)
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second passed, and returns whether it held.
+func waitFor(cond func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return cond()
+}
+
+// Opened pull requests are acknowledged with the skipped status, and placed once their grace period is over.
+func TestOpenedGracePeriod(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"OPENED_GRACE_PERIOD": "50ms", "STATUS_SKIPPED": "204"})
+	pr := f.pr(1)
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusNoContent {
+		t.Errorf("got status %d, want the skipped status %d", rec.Code, http.StatusNoContent)
+	}
+	if !waitFor(func() bool { return f.cardOf(pr) != nil }) {
+		t.Error("got no card after the grace period")
+	}
+}
+
+// Placements held back aren't applied while the bot is paused.
+func TestOpenedGracePeriodPaused(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"OPENED_GRACE_PERIOD": "20ms"})
+	pr := f.pr(1)
+
+	sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+	s.setPaused(true)
+	time.Sleep(100 * time.Millisecond)
+
+	if card := f.cardOf(pr); card != nil {
+		t.Errorf("got card %d placed while paused, want none", card.ID)
+	}
+}
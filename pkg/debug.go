@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// debugResponseWriter reports how many GitHub calls a webhook triggered and how long it took
+// in the X-GitHub-Calls and X-Processing-Ms response headers.
+type debugResponseWriter struct {
+	http.ResponseWriter
+	start       time.Time
+	calls       int32
+	wroteHeader bool
+}
+
+func newDebugResponseWriter(w http.ResponseWriter) *debugResponseWriter {
+	return &debugResponseWriter{ResponseWriter: w, start: time.Now()}
+}
+
+func (w *debugResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("X-GitHub-Calls", strconv.Itoa(int(atomic.LoadInt32(&w.calls))))
+		w.Header().Set("X-Processing-Ms", strconv.FormatInt(time.Since(w.start).Nanoseconds()/int64(time.Millisecond), 10))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *debugResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// recentMoves remembers the column each card was last placed in, to skip placing it there again
+// within a short window, such as when a pull request is opened and pushed to right away.
+type recentMoves struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]recentMove
+}
+
+type recentMove struct {
+	column string
+	at     time.Time
+}
+
+func newRecentMoves(window time.Duration) *recentMoves {
+	return &recentMoves{window: window, entries: make(map[string]recentMove)}
+}
+
+// seen returns true if the card was placed in the column within the window.
+func (r *recentMoves) seen(key, column string) bool {
+	if r.window <= 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[key]
+	return ok && e.column == column && time.Since(e.at) < r.window
+}
+
+// record remembers that the card was just placed in the column, and forgets expired entries.
+func (r *recentMoves) record(key, column string) {
+	if r.window <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, e := range r.entries {
+		if now.Sub(e.at) >= r.window {
+			delete(r.entries, k)
+		}
+	}
+	r.entries[key] = recentMove{column: column, at: now}
+}
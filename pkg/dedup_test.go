@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Placing a card in the column it was just placed in is skipped without looking up the card.
+func TestBackToBackMovesDeduplicated(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"MOVE_DEDUP_WINDOW": "1m"})
+	pr := f.pr(1)
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusCreated {
+		t.Fatalf("first event: got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	f.resetRequests()
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusOK {
+		t.Errorf("second event: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	for _, r := range f.requests() {
+		if strings.Contains(r.path, "/cards") {
+			t.Errorf("second event: got request %v, want no card requests", r)
+		}
+	}
+}
+
+func TestRecentMoves(t *testing.T) {
+	r := newRecentMoves(20 * time.Millisecond)
+	r.record("card", IN_REVIEW)
+	if !r.seen("card", IN_REVIEW) {
+		t.Error("got the move forgotten within the window")
+	}
+	if r.seen("card", IN_PROGRESS) || r.seen("other", IN_REVIEW) {
+		t.Error("got a move seen for another column or card")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if r.seen("card", IN_REVIEW) {
+		t.Error("got the move remembered after the window")
+	}
+	disabled := newRecentMoves(0)
+	disabled.record("card", IN_REVIEW)
+	if disabled.seen("card", IN_REVIEW) {
+		t.Error("got the move remembered without a window")
+	}
+}
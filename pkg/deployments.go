@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// handleDeploymentStatus moves the cards of the pull requests included in a successful deployment
+// to the release environment from PENDING_RELEASE to RELEASED.
+// A pull request is included if its merge commit is an ancestor of the deployed commit.
+func (s *server) handleDeploymentStatus(ctx context.Context, w http.ResponseWriter, e *github.DeploymentStatusEvent) {
+	env := e.GetDeployment().GetEnvironment()
+	if s.cfg.releaseEnvironment == "" || env != s.cfg.releaseEnvironment || e.GetDeploymentStatus().GetState() != "success" {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	sha := e.GetDeployment().GetSHA()
+
+	boards, err := s.repoBoards(ctx, e.GetRepo())
+	if err != nil {
+		s.fail(ctx, w, "getting project board", err)
+		return
+	}
+	released := 0
+	for _, b := range boards {
+		n, err := s.release(ctx, b, sha)
+		if err != nil {
+			s.fail(ctx, w, fmt.Sprintf("releasing cards of deployment %s to %s", sha, env), err)
+			return
+		}
+		released += n
+	}
+	log.Printf("🚀 deployment %s to %s released %d cards\n", sha, env, released)
+	if released == 0 {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	w.WriteHeader(s.cfg.statusCode(moved))
+}
+
+// release moves the cards pending release on the board whose pull requests are included in the commit,
+// and returns how many were moved.
+func (s *server) release(ctx context.Context, b *board, sha string) (int, error) {
+	released := 0
+	for _, lane := range b.withSetsOf(PENDING_RELEASE) {
+		n, err := s.releaseColumn(ctx, b, lane, sha)
+		released += n
+		if err != nil {
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// releaseColumn moves the cards of the PENDING_RELEASE column of the lane included in the commit,
+// placing them in the columns of their pull request's set on the board.
+func (s *server) releaseColumn(ctx context.Context, b, lane *board, sha string) (int, error) {
+	if lane.columns[RELEASED] == nil {
+		return 0, nil
+	}
+	cards, err := s.listCards(ctx, lane, PENDING_RELEASE)
+	if err != nil {
+		return 0, err
+	}
+	released := 0
+	for _, card := range cards {
+		if card.GetContentURL() == "" {
+			continue
+		}
+		pr, err := s.pullRequestOf(ctx, card)
+		if err != nil {
+			return released, err
+		}
+		if pr == nil || !pr.GetMerged() {
+			continue
+		}
+		included, err := s.isAncestor(ctx, pr.GetBase().GetRepo(), pr.GetMergeCommitSHA(), sha)
+		if err != nil {
+			return released, err
+		}
+		if !included {
+			continue
+		}
+		_, o, err := s.placeCard(ctx, b, pr, placement{column: RELEASED, from: []string{PENDING_RELEASE}})
+		if err != nil {
+			return released, err
+		}
+		if o == moved {
+			released++
+		}
+	}
+	return released, nil
+}
+
+// pullRequestOf returns the pull request the card links to, or nil if it links to an issue.
+func (s *server) pullRequestOf(ctx context.Context, card *github.ProjectCard) (*github.PullRequest, error) {
+	req, err := s.client.NewRequest(http.MethodGet, card.GetContentURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var issue github.Issue
+	if _, err := s.client.Do(ctx, req, &issue); err != nil {
+		return nil, fmt.Errorf("get content of card %d: %w", card.GetID(), err)
+	}
+	if !issue.IsPullRequest() {
+		return nil, nil
+	}
+	if req, err = s.client.NewRequest(http.MethodGet, issue.GetPullRequestLinks().GetURL(), nil); err != nil {
+		return nil, err
+	}
+	var pr github.PullRequest
+	if _, err := s.client.Do(ctx, req, &pr); err != nil {
+		return nil, fmt.Errorf("get pull request of card %d: %w", card.GetID(), err)
+	}
+	return &pr, nil
+}
+
+// isAncestor returns true if the commit base is an ancestor of, or the same as, the commit head in the repository.
+func (s *server) isAncestor(ctx context.Context, repo *github.Repository, base, head string) (bool, error) {
+	comparison, _, err := s.client.Repositories.CompareCommits(ctx, repo.GetOwner().GetLogin(), repo.GetName(), base, head)
+	if err != nil {
+		return false, fmt.Errorf("compare commits %s and %s: %w", base, head, err)
+	}
+	switch comparison.GetStatus() {
+	case "ahead", "identical":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// handleRepositoryDispatch reconciles the board of the repository when a workflow dispatches the configured event type,
+// such as with `gh api repos/OWNER/REPO/dispatches -f event_type=reconcile-board`.
+func (s *server) handleRepositoryDispatch(ctx context.Context, w http.ResponseWriter, e *github.RepositoryDispatchEvent) {
+	if s.cfg.dispatchAction == "" || e.GetAction() != s.cfg.dispatchAction {
+		log.Printf("🤷‍♀️ repository dispatch %s is not handled\n", e.GetAction())
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	log.Printf("🔁 reconciling board on repository dispatch %s from %s\n", e.GetAction(), e.GetSender().GetLogin())
+	if err := s.reconcile(ctx, e.GetRepo()); err != nil {
+		if repo, ok := s.cfg.skipsBoardError(err); ok {
+			s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping events of %s\n", err, repo)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		s.fail(ctx, w, "reconciling board", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func dispatchEvent(action string, repo *github.Repository) *github.RepositoryDispatchEvent {
+	return &github.RepositoryDispatchEvent{
+		Action: github.String(action),
+		Repo:   repo,
+		Sender: &github.User{Login: github.String("octocat")},
+	}
+}
+
+// Repository dispatches of the configured action reconcile the board, others are acknowledged.
+func TestRepositoryDispatch(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"DISPATCH_ACTION": "reconcile-board"})
+	pr := f.pr(1)
+
+	if rec := sendWebhook(t, s, "repository_dispatch", dispatchEvent("deploy", pr.GetBase().GetRepo())); rec.Code != http.StatusAccepted || f.cardOf(pr) != nil {
+		t.Errorf("other action: got status %d and card %v, want %d and no card", rec.Code, f.cardOf(pr), http.StatusAccepted)
+	}
+	if rec := sendWebhook(t, s, "repository_dispatch", dispatchEvent("reconcile-board", pr.GetBase().GetRepo())); rec.Code != http.StatusOK {
+		t.Errorf("reconcile-board: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if card := f.cardOf(pr); card == nil || card.column != f.column(IN_REVIEW) {
+		t.Errorf("reconcile-board: got card %v, want the open pull request placed in %s", card, IN_REVIEW)
+	}
+}
+
+// Repository dispatches reconcile the board of the repository they were dispatched to.
+func TestRepositoryDispatchReconcilesItsRepository(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	other := f.addProject("acme/widgets", PROJECT_NAME, "open")
+	review := f.addColumn(other.ID, IN_REVIEW)
+	for _, name := range []string{BACKLOG, IN_PROGRESS, PENDING_RELEASE} {
+		f.addColumn(other.ID, name)
+	}
+	s := newTestServer(t, f, map[string]string{"DISPATCH_ACTION": "reconcile-board"})
+	home := f.pr(1)
+	pr := f.newRepoPR("acme", "widgets", 2)
+	f.addPR(pr)
+
+	if rec := sendWebhook(t, s, "repository_dispatch", dispatchEvent("reconcile-board", pr.GetBase().GetRepo())); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if cards := f.cardsIn(review.ID); len(cards) != 1 || cards[0].contentID != pr.GetID() {
+		t.Errorf("got cards %+v in the %s column of acme/widgets, want the card of its pull request", cards, IN_REVIEW)
+	}
+	if card := f.cardOf(home); card != nil {
+		t.Errorf("got card %+v for the pull request of %s/%s, want its board left alone", card, OWNER, REPO)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// draftColumns remembers the column each card was in before its pull request was converted to a draft,
+// so that the card goes back there once the pull request is ready for review again.
+// They're kept in memory: after a restart, cards of pull requests ready for review go to IN_REVIEW.
+type draftColumns struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func newDraftColumns() *draftColumns {
+	return &draftColumns{entries: make(map[string]string)}
+}
+
+// save remembers the column the card was in before becoming a draft.
+func (d *draftColumns) save(key, column string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[key] = column
+}
+
+// take returns the column the card was in before becoming a draft and forgets it.
+func (d *draftColumns) take(key string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	column, ok := d.entries[key]
+	delete(d.entries, key)
+	return column, ok
+}
+
+// cardKey identifies the card of the pull request on the board.
+func cardKey(b *board, pr *github.PullRequest) string {
+	return fmt.Sprintf("%d/%d", b.project.GetID(), pr.GetID())
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+// A pull request converted to draft and back returns to the column it was in before, not just In review.
+func TestDraftRoundTrip(t *testing.T) {
+	for _, from := range []string{IN_REVIEW, PENDING_RELEASE} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"MOVE_ON_DRAFT": "true"})
+		pr := f.pr(1)
+		f.addCard(f.column(from), pr)
+
+		sendWebhook(t, s, "pull_request", prEvent("converted_to_draft", pr))
+		if got := f.cardOf(pr).column; got != f.column(IN_PROGRESS) {
+			t.Errorf("from %s: got draft card in column %d, want %s", from, got, IN_PROGRESS)
+		}
+		sendWebhook(t, s, "pull_request", prEvent("ready_for_review", pr))
+		if got := f.cardOf(pr).column; got != f.column(from) {
+			t.Errorf("from %s: got ready card in column %d, want it back in %s", from, got, from)
+		}
+		f.close()
+	}
+}
+
+// Without a remembered column, such as for pull requests opened as drafts, ready pull requests go to In review.
+func TestReadyWithoutDraftColumn(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"MOVE_ON_DRAFT": "true"})
+	pr := f.pr(1)
+	f.addCard(f.column(IN_PROGRESS), pr)
+
+	sendWebhook(t, s, "pull_request", prEvent("ready_for_review", pr))
+	if got := f.cardOf(pr).column; got != f.column(IN_REVIEW) {
+		t.Errorf("got ready card in column %d, want %s", got, IN_REVIEW)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// missingProjectError is returned when the repository doesn't have the configured project board,
+// or when the board is closed.
+type missingProjectError struct {
+	status int
+	msg    string
+	closed bool
+	// repo is the repository, named "owner/name", whose project board couldn't be resolved.
+	repo string
+}
+
+func (e *missingProjectError) Error() string { return e.msg }
+
+// multiError collects the errors of independent operations, such as placing a card on several boards.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// statusOf returns the HTTP status code to reply with for err.
+func statusOf(err error) int {
+	var errs multiError
+	if errors.As(err, &errs) && len(errs) > 0 {
+		return statusOf(errs[0])
+	}
+	var me *missingProjectError
+	if errors.As(err, &me) {
+		return me.status
+	}
+	var ge *github.ErrorResponse
+	if errors.As(err, &ge) && ge.Response != nil {
+		// GitHub replying with anything but an error, such as a redirect that wasn't followed, is a bad gateway.
+		if ge.Response.StatusCode < http.StatusBadRequest {
+			return http.StatusBadGateway
+		}
+		return ge.Response.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// report logs and records the error that happened while doing stage, and returns it with hints for the user.
+func (s *server) report(ctx context.Context, stage string, err error) error {
+	err = explainMoved(explainForbidden(err))
+	log.Printf("🚨 error %s: err=%s\n", stage, err)
+	reportedErrors.inc()
+	s.errors.add(recordedError{
+		Time:     time.Now(),
+		Delivery: deliveryID(ctx),
+		Stage:    stage,
+		Message:  err.Error(),
+	})
+	return err
+}
+
+// fail reports the error that happened while doing stage, and replies with it.
+func (s *server) fail(ctx context.Context, w http.ResponseWriter, stage string, err error) {
+	err = s.report(ctx, stage, err)
+	http.Error(w, err.Error(), statusOf(err))
+}
+
+type deliveryKey struct{}
+
+// withDelivery returns a context for processing the webhook delivery with the ID.
+func withDelivery(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, deliveryKey{}, id)
+}
+
+// deliveryID returns the ID of the webhook delivery being processed, empty outside webhooks.
+func deliveryID(ctx context.Context) string {
+	id, _ := ctx.Value(deliveryKey{}).(string)
+	return id
+}
+
+// recordedError is an error reported while processing a webhook or a background task.
+type recordedError struct {
+	Time     time.Time `json:"time"`
+	Delivery string    `json:"delivery,omitempty"`
+	Stage    string    `json:"stage"`
+	Message  string    `json:"message"`
+}
+
+// errorLog keeps the last reported errors, up to its size.
+type errorLog struct {
+	mu     sync.Mutex
+	size   int
+	next   int
+	errors []recordedError
+}
+
+func (l *errorLog) add(e recordedError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size <= 0 {
+		return
+	}
+	if len(l.errors) < l.size {
+		l.errors = append(l.errors, e)
+		return
+	}
+	l.errors[l.next] = e
+	l.next = (l.next + 1) % l.size
+}
+
+// list returns the recorded errors, oldest first.
+func (l *errorLog) list() []recordedError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append(append([]recordedError{}, l.errors[l.next:]...), l.errors[:l.next]...)
+}
+
+// explainForbidden names the permission the token likely lacks when GitHub answered 403 Forbidden,
+// which is what fine-grained personal access tokens get for operations outside their scopes.
+func explainForbidden(err error) error {
+	var ge *github.ErrorResponse
+	if !errors.As(err, &ge) || ge.Response == nil || ge.Response.StatusCode != http.StatusForbidden {
+		return err
+	}
+	// Fine-grained tokens are told which permissions the endpoint accepts.
+	if accepted := ge.Response.Header.Get("X-Accepted-GitHub-Permissions"); accepted != "" {
+		return fmt.Errorf("token lacks one of the permissions %q: %w", accepted, err)
+	}
+	req := ge.Response.Request
+	if req == nil {
+		return err
+	}
+	access := "write"
+	if req.Method == http.MethodGet {
+		access = "read"
+	}
+	var scope string
+	switch path := req.URL.Path; {
+	case strings.HasPrefix(strings.TrimPrefix(path, "/api/v3"), "/orgs/") && strings.Contains(path, "/projects"):
+		scope = "organization projects"
+	case strings.Contains(path, "/projects"):
+		scope = "projects"
+	case strings.Contains(path, "/pulls"):
+		scope = "pull requests"
+	case strings.Contains(path, "/issues"):
+		scope = "issues"
+	default:
+		return err
+	}
+	return fmt.Errorf("token lacks '%s: %s': %w", scope, access, err)
+}
+
+// explainMoved tells the user to update the configured repository when GitHub redirected the request,
+// which is how it answers for renamed or transferred repositories unless redirects are followed.
+// The repository is the one of the redirected request, such as the repository of the event.
+func explainMoved(err error) error {
+	var ge *github.ErrorResponse
+	if !errors.As(err, &ge) || ge.Response == nil {
+		return err
+	}
+	switch ge.Response.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return err
+	}
+	repo := "the repository"
+	if req := ge.Response.Request; req != nil {
+		if name, ok := repoOfPath(req.URL.Path); ok {
+			repo = "repository " + name
+		}
+	}
+	return fmt.Errorf("%s moved to %s, update the configured repository or set FOLLOW_REPO_REDIRECTS=true: %w",
+		repo, ge.Response.Header.Get("Location"), err)
+}
+
+// repoOfPath returns the repository, as "owner/name", of a GitHub API path such as /repos/OWNER/REPO/projects.
+func repoOfPath(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "repos" && parts[i+1] != "" && parts[i+2] != "" {
+			return parts[i+1] + "/" + parts[i+2], true
+		}
+	}
+	return "", false
+}
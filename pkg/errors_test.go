@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// A moved repository surfaces as a bad gateway telling to update the configured repository,
+// unless redirects are followed.
+func TestRepositoryMoved(t *testing.T) {
+	for _, follow := range []bool{false, true} {
+		f := newFakeGitHub()
+		env := map[string]string{}
+		if follow {
+			env["FOLLOW_REPO_REDIRECTS"] = "true"
+		}
+		s := newTestServer(t, f, env)
+		f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+			if req.URL.Path != "/repos/"+OWNER+"/"+REPO+"/projects" || req.URL.Query().Get("moved") != "" {
+				return false
+			}
+			w.Header().Set("Location", req.URL.Path+"?moved=true")
+			writeFakeJSON(w, http.StatusMovedPermanently, map[string]string{"message": "Moved Permanently"})
+			return true
+		})
+
+		rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1)))
+		if follow {
+			if rec.Code != http.StatusCreated || len(f.cardsIn(f.column(IN_REVIEW))) != 1 {
+				t.Errorf("following redirects: got status %d and cards %v, want the card created", rec.Code, f.cardsIn(f.column(IN_REVIEW)))
+			}
+		} else {
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("got status %d, want %d", rec.Code, http.StatusBadGateway)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, "moved to") || !strings.Contains(body, "FOLLOW_REPO_REDIRECTS") {
+				t.Errorf("got body %q, want it to tell the repository moved", body)
+			}
+		}
+		f.close()
+	}
+}
+
+// The moved repository is the one of the event, not OWNER/REPO.
+func TestRepositoryMovedNamesEventRepository(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		if req.URL.Path != "/repos/acme/widgets/projects" {
+			return false
+		}
+		w.Header().Set("Location", "/repos/acme/gadgets/projects")
+		writeFakeJSON(w, http.StatusMovedPermanently, map[string]string{"message": "Moved Permanently"})
+		return true
+	})
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.newRepoPR("acme", "widgets", 1)))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "repository acme/widgets moved to") || strings.Contains(body, OWNER) {
+		t.Errorf("got body %q, want it to tell acme/widgets moved", body)
+	}
+}
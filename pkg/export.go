@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// exportHandler streams the cards of the managed columns of the project board as CSV, one row per card.
+// The board is the one of the repository in the repo query parameter, or of the home repository.
+// Rows are flushed as they're resolved, so an error midway ends the export early and is only reported.
+func (s *server) exportHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	repo, err := adminRepository(req.URL.Query().Get("repo"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := context.Background()
+	b, err := s.repoBoard(ctx, repo)
+	if err != nil {
+		s.fail(ctx, w, "getting project board", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="board.csv"`)
+	out := csv.NewWriter(w)
+	write := func(record ...string) {
+		out.Write(record)
+		out.Flush()
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	write("pr_number", "title", "author", "column", "updated_at")
+	for _, name := range s.cfg.columns {
+		for _, lane := range b.withSetsOf(name) {
+			column := lane.columns[name]
+			cards, err := s.listCards(ctx, lane, name)
+			if err != nil {
+				s.report(ctx, "exporting board", err)
+				return
+			}
+			for _, card := range cards {
+				if card.GetContentURL() == "" {
+					continue
+				}
+				pr, err := s.pullRequestOf(ctx, card)
+				if err != nil {
+					s.report(ctx, "exporting board", fmt.Errorf("resolve card %d: %w", card.GetID(), err))
+					return
+				}
+				if pr == nil {
+					continue
+				}
+				write(strconv.Itoa(pr.GetNumber()), pr.GetTitle(), pr.GetUser().GetLogin(), column.GetName(),
+					card.GetUpdatedAt().UTC().Format(time.RFC3339))
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Each card is exported once, even in the columns column sets share with the default columns.
+func TestExportSharedColumnsOnce(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	fixing := f.addColumn(101, "Fixing")
+	s := newTestServer(t, f, map[string]string{
+		"COLUMN_SETS":             "bugs",
+		"COLUMN_SET_BUGS_COLUMNS": "In review=Fixing",
+		"COLUMN_SET_BUGS_LABELS":  "bug",
+	})
+	f.addCard(f.column(IN_PROGRESS), f.pr(1))
+	f.addCard(fixing.ID, f.pr(2))
+	f.addCard(f.column(IN_REVIEW), f.pr(3))
+
+	rec := httptest.NewRecorder()
+	s.exportHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/export", nil), nil)
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	columns := make(map[string]int)
+	for _, row := range rows[1:] {
+		columns[row[3]]++
+	}
+	if len(rows) != 4 || columns[IN_PROGRESS] != 1 || columns["Fixing"] != 1 || columns[IN_REVIEW] != 1 {
+		t.Errorf("got rows %v, want one row per card", rows)
+	}
+}
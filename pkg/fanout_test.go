@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Cards are placed on the extra project boards too, and a board failing doesn't undo the others.
+func TestFanOut(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"GH_EXTRA_PROJECTS": "Program"})
+	program := f.addProject(OWNER+"/"+REPO, "Program", "open")
+	programColumns := make(map[string]*fakeColumn)
+	for _, name := range allColumns {
+		programColumns[name] = f.addColumn(program.ID, name)
+	}
+
+	pr := f.pr(1)
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	for _, column := range []int64{f.column(IN_REVIEW), programColumns[IN_REVIEW].ID} {
+		if got := f.cardsIn(column); len(got) != 1 {
+			t.Errorf("got cards %v in column %d, want the card on each board", got, column)
+		}
+	}
+
+	// Creating the card on the program board fails.
+	failing := "/projects/columns/" + strconv.FormatInt(programColumns[IN_REVIEW].ID, 10) + "/cards"
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		if req.Method != http.MethodPost || req.URL.Path != failing {
+			return false
+		}
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]string{"message": "Server Error"})
+		return true
+	})
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(2)))
+	if rec.Code != http.StatusMultiStatus || !strings.Contains(rec.Body.String(), "project Program") {
+		t.Errorf("got status %d and body %q, want %d with the failing board", rec.Code, rec.Body.String(), http.StatusMultiStatus)
+	}
+	if f.cardOf(f.pr(2)) == nil {
+		t.Error("got no card on the sprint board, want it created despite the program board failing")
+	}
+
+	// Every board failing is an error.
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/cards") {
+			return false
+		}
+		writeFakeJSON(w, http.StatusInternalServerError, map[string]string{"message": "Server Error"})
+		return true
+	})
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(3))); rec.Code != http.StatusInternalServerError {
+		t.Errorf("all boards failing: got status %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,781 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// testSecret signs the webhooks sent by the tests.
+const testSecret = "test-secret"
+
+// fakeGitHub is an in-memory GitHub API serving the endpoints the bot calls, and recording the requests it gets.
+// It starts with the project board PROJECT_NAME of the repository OWNER/REPO and its default columns.
+type fakeGitHub struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	nextID   int64
+	projects []*fakeProject
+	columns  []*fakeColumn
+	cards    []*fakeCard
+	pulls    []*github.PullRequest
+	// issues are the issues that aren't pull requests.
+	issues   []*github.Issue
+	comments map[string][]string
+	// reviews are the reviews of the pull requests in chronological order, keyed like comments.
+	reviews   map[string][]*github.PullRequestReview
+	remaining int
+	log       []fakeRequest
+	// intercept answers the requests it returns true for instead of the fake, such as to inject failures.
+	intercept func(w http.ResponseWriter, req *http.Request) bool
+}
+
+type fakeProject struct {
+	ID     int64  `json:"id"`
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	// owner is the "owner/name" of the repository, or the login of the organization, listing the project.
+	owner string
+}
+
+type fakeColumn struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	project int64
+}
+
+type fakeCard struct {
+	ID         int64     `json:"id"`
+	Note       string    `json:"note,omitempty"`
+	ContentURL string    `json:"content_url,omitempty"`
+	Archived   bool      `json:"archived"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	column     int64
+	contentID  int64
+}
+
+// fakeRequest is a request the fake GitHub API received.
+type fakeRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+func (r fakeRequest) String() string { return r.method + " " + r.path }
+
+// newFakeGitHub starts a fake GitHub API, to be closed at the end of the test.
+func newFakeGitHub() *fakeGitHub {
+	f := &fakeGitHub{nextID: 100, remaining: 5000, comments: make(map[string][]string), reviews: make(map[string][]*github.PullRequestReview)}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	proj := f.addProject(OWNER+"/"+REPO, PROJECT_NAME, "open")
+	for _, name := range allColumns {
+		f.addColumn(proj.ID, name)
+	}
+	return f
+}
+
+func (f *fakeGitHub) close() { f.server.Close() }
+
+// url is the base URL of the fake API, as configured with GITHUB_API_URL.
+func (f *fakeGitHub) url() *url.URL {
+	u, _ := url.Parse(f.server.URL + "/")
+	return u
+}
+
+func (f *fakeGitHub) addProject(owner, name, state string) *fakeProject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	proj := &fakeProject{ID: f.nextID, Number: len(f.projects) + 1, Name: name, State: state, owner: owner}
+	f.projects = append(f.projects, proj)
+	return proj
+}
+
+func (f *fakeGitHub) addColumn(project int64, name string) *fakeColumn {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	column := &fakeColumn{ID: f.nextID, Name: name, project: project}
+	f.columns = append(f.columns, column)
+	return column
+}
+
+// column returns the ID of the first column with the name, on any project.
+func (f *fakeGitHub) column(name string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, column := range f.columns {
+		if column.Name == name {
+			return column.ID
+		}
+	}
+	return 0
+}
+
+// newPR returns the n-th pull request of OWNER/REPO, open against master, without adding it to the fake.
+func (f *fakeGitHub) newPR(n int) *github.PullRequest {
+	return f.newRepoPR(OWNER, REPO, n)
+}
+
+// newRepoPR returns the n-th pull request of the repository owner/name, open against master,
+// without adding it to the fake.
+func (f *fakeGitHub) newRepoPR(owner, name string, n int) *github.PullRequest {
+	repo := &github.Repository{
+		Name:     github.String(name),
+		FullName: github.String(owner + "/" + name),
+		Owner:    &github.User{Login: github.String(owner)},
+	}
+	api := fmt.Sprintf("%srepos/%s/%s", f.url(), owner, name)
+	return &github.PullRequest{
+		ID:       github.Int64(int64(1000 + n)),
+		NodeID:   github.String(fmt.Sprintf("PR_%d", n)),
+		Number:   github.Int(n),
+		Title:    github.String(fmt.Sprintf("Feature %d", n)),
+		State:    github.String("open"),
+		HTMLURL:  github.String(fmt.Sprintf("https://github.com/%s/%s/pull/%d", owner, name, n)),
+		URL:      github.String(fmt.Sprintf("%s/pulls/%d", api, n)),
+		IssueURL: github.String(fmt.Sprintf("%s/issues/%d", api, n)),
+		User:     &github.User{Login: github.String("octocat")},
+		Head: &github.PullRequestBranch{
+			Ref:  github.String(fmt.Sprintf("feature-%d", n)),
+			SHA:  github.String(fmt.Sprintf("sha-%d", n)),
+			Repo: repo,
+		},
+		Base: &github.PullRequestBranch{Ref: github.String("master"), Repo: repo},
+	}
+}
+
+// pr adds the n-th pull request of OWNER/REPO to the fake and returns it.
+func (f *fakeGitHub) pr(n int) *github.PullRequest {
+	pr := f.newPR(n)
+	f.addPR(pr)
+	return pr
+}
+
+// addPR adds a copy of the pull request to the fake, replacing the one with the same ID.
+func (f *fakeGitHub) addPR(pr *github.PullRequest) {
+	var stored github.PullRequest
+	b, _ := json.Marshal(pr)
+	json.Unmarshal(b, &stored)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, p := range f.pulls {
+		if p.GetID() == pr.GetID() {
+			f.pulls[i] = &stored
+			return
+		}
+	}
+	f.pulls = append(f.pulls, &stored)
+}
+
+// addCard adds a card linked to the pull request to the column, as if it was added by hand.
+func (f *fakeGitHub) addCard(column int64, pr *github.PullRequest) *fakeCard {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	card := &fakeCard{
+		ID:         f.nextID,
+		ContentURL: pr.GetIssueURL(),
+		UpdatedAt:  time.Now(),
+		column:     column,
+		contentID:  pr.GetID(),
+	}
+	f.cards = append(f.cards, card)
+	return card
+}
+
+// issue adds the n-th issue of OWNER/REPO, open and not a pull request, to the fake and returns it.
+func (f *fakeGitHub) issue(n int) *github.Issue {
+	api := fmt.Sprintf("%srepos/%s/%s", f.url(), OWNER, REPO)
+	issue := &github.Issue{
+		ID:      github.Int64(int64(5000 + n)),
+		Number:  github.Int(n),
+		Title:   github.String(fmt.Sprintf("Bug %d", n)),
+		State:   github.String("open"),
+		URL:     github.String(fmt.Sprintf("%s/issues/%d", api, n)),
+		HTMLURL: github.String(fmt.Sprintf("https://github.com/%s/%s/issues/%d", OWNER, REPO, n)),
+		User:    &github.User{Login: github.String("octocat")},
+		Repository: &github.Repository{
+			Name:     github.String(REPO),
+			FullName: github.String(OWNER + "/" + REPO),
+			Owner:    &github.User{Login: github.String(OWNER)},
+		},
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.issues = append(f.issues, issue)
+	return issue
+}
+
+// addIssueCard adds a card linked to the issue to the column, as if it was added by hand.
+func (f *fakeGitHub) addIssueCard(column int64, issue *github.Issue) *fakeCard {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	card := &fakeCard{
+		ID:         f.nextID,
+		ContentURL: issue.GetURL(),
+		UpdatedAt:  time.Now(),
+		column:     column,
+		contentID:  issue.GetID(),
+	}
+	f.cards = append(f.cards, card)
+	return card
+}
+
+// issueCardOf returns the card linked to the issue, or nil if there is none.
+func (f *fakeGitHub) issueCardOf(issue *github.Issue) *fakeCard {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, card := range f.cards {
+		if card.contentID == issue.GetID() {
+			c := *card
+			return &c
+		}
+	}
+	return nil
+}
+
+// age changes the card as if it was last updated d ago.
+func (f *fakeGitHub) age(card *fakeCard, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.cards {
+		if c.ID == card.ID {
+			c.UpdatedAt = time.Now().Add(-d)
+		}
+	}
+}
+
+// cardsIn returns the cards in the column that aren't archived, in order.
+func (f *fakeGitHub) cardsIn(column int64) []fakeCard {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var cards []fakeCard
+	for _, card := range f.cards {
+		if card.column == column && !card.Archived {
+			cards = append(cards, *card)
+		}
+	}
+	return cards
+}
+
+// cardOf returns the card linked to the pull request, or nil if there is none.
+func (f *fakeGitHub) cardOf(pr *github.PullRequest) *fakeCard {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, card := range f.cards {
+		if card.contentID == pr.GetID() {
+			c := *card
+			return &c
+		}
+	}
+	return nil
+}
+
+// addReview adds a review in the state, such as APPROVED, by the reviewer to the pull request.
+func (f *fakeGitHub) addReview(pr *github.PullRequest, reviewer, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := pr.GetBase().GetRepo().GetFullName() + "#" + strconv.Itoa(pr.GetNumber())
+	f.reviews[key] = append(f.reviews[key], &github.PullRequestReview{
+		User:  &github.User{Login: github.String(reviewer)},
+		State: github.String(state),
+	})
+}
+
+// commentsOn returns the bodies of the comments made on the pull request.
+func (f *fakeGitHub) commentsOn(pr *github.PullRequest) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.comments[pr.GetBase().GetRepo().GetFullName()+"#"+strconv.Itoa(pr.GetNumber())]...)
+}
+
+// requests returns the requests received so far.
+func (f *fakeGitHub) requests() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.log...)
+}
+
+// mutations returns the requests received so far that change something.
+func (f *fakeGitHub) mutations() []fakeRequest {
+	var mutations []fakeRequest
+	for _, r := range f.requests() {
+		if r.method != http.MethodGet && r.path != "/graphql" {
+			mutations = append(mutations, r)
+		}
+	}
+	return mutations
+}
+
+// resetRequests forgets the requests received so far.
+func (f *fakeGitHub) resetRequests() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = nil
+}
+
+// setIntercept answers the requests fn returns true for with fn instead of the fake.
+func (f *fakeGitHub) setIntercept(fn func(w http.ResponseWriter, req *http.Request) bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.intercept = fn
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	f.mu.Lock()
+	f.log = append(f.log, fakeRequest{method: req.Method, path: req.URL.Path, body: string(body)})
+	intercept := f.intercept
+	f.mu.Unlock()
+	if intercept != nil && intercept(w, req) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	route := req.Method + " " + routePattern(parts)
+	switch route {
+	case "GET rate_limit":
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+			"resources": map[string]interface{}{
+				"core": map[string]int64{"limit": 5000, "remaining": int64(f.remaining), "reset": time.Now().Add(time.Hour).Unix()},
+			},
+		})
+	case "GET repos/*/*/projects", "GET orgs/*/projects":
+		owner := parts[1]
+		if parts[0] == "repos" {
+			owner += "/" + parts[2]
+		}
+		projects := []*fakeProject{}
+		for _, proj := range f.projects {
+			if strings.EqualFold(proj.owner, owner) {
+				projects = append(projects, proj)
+			}
+		}
+		writeFakePage(w, req, projects)
+	case "GET projects/*":
+		for _, proj := range f.projects {
+			if strconv.FormatInt(proj.ID, 10) == parts[1] {
+				writeFakeJSON(w, http.StatusOK, proj)
+				return
+			}
+		}
+		writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case "GET projects/*/columns":
+		columns := []*fakeColumn{}
+		for _, column := range f.columns {
+			if strconv.FormatInt(column.project, 10) == parts[1] {
+				columns = append(columns, column)
+			}
+		}
+		writeFakePage(w, req, columns)
+	case "POST projects/*/columns":
+		var opts github.ProjectColumnOptions
+		json.Unmarshal(body, &opts)
+		project, _ := strconv.ParseInt(parts[1], 10, 64)
+		f.nextID++
+		column := &fakeColumn{ID: f.nextID, Name: opts.Name, project: project}
+		f.columns = append(f.columns, column)
+		writeFakeJSON(w, http.StatusCreated, column)
+	case "POST projects/columns/*/moves":
+		var opts github.ProjectColumnMoveOptions
+		json.Unmarshal(body, &opts)
+		f.moveColumn(parts[2], opts.Position)
+		writeFakeJSON(w, http.StatusCreated, struct{}{})
+	case "GET projects/columns/*/cards":
+		state := req.URL.Query().Get("archived_state")
+		cards := []*fakeCard{}
+		for _, card := range f.cards {
+			if strconv.FormatInt(card.column, 10) == parts[2] && (state == "all" || card.Archived == (state == "archived")) {
+				cards = append(cards, card)
+			}
+		}
+		writeFakePage(w, req, cards)
+	case "POST projects/columns/*/cards":
+		var opts github.ProjectCardOptions
+		json.Unmarshal(body, &opts)
+		column, _ := strconv.ParseInt(parts[2], 10, 64)
+		card := &fakeCard{Note: opts.Note, UpdatedAt: time.Now(), column: column, contentID: opts.ContentID}
+		if opts.ContentID != 0 {
+			// Like GitHub, content can only be on a project once, even when its card is archived.
+			for _, other := range f.cards {
+				if other.contentID == opts.ContentID && f.projectOf(other.column) == f.projectOf(column) {
+					writeFakeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+						"message": "Validation Failed",
+						"errors":  []map[string]string{{"resource": "ProjectCard", "code": "unprocessable", "message": "Project already has the associated issue"}},
+					})
+					return
+				}
+			}
+			for _, pr := range f.pulls {
+				if pr.GetID() == opts.ContentID {
+					card.ContentURL = pr.GetIssueURL()
+				}
+			}
+			for _, issue := range f.issues {
+				if issue.GetID() == opts.ContentID {
+					card.ContentURL = issue.GetURL()
+				}
+			}
+		}
+		f.nextID++
+		card.ID = f.nextID
+		f.cards = append(f.cards, card)
+		writeFakeJSON(w, http.StatusCreated, card)
+	case "POST projects/columns/cards/*/moves":
+		var opts github.ProjectCardMoveOptions
+		json.Unmarshal(body, &opts)
+		card := f.card(parts[3])
+		if card == nil {
+			writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if opts.ColumnID != 0 {
+			card.column = opts.ColumnID
+		}
+		card.UpdatedAt = time.Now()
+		f.positionCard(card, opts.Position)
+		writeFakeJSON(w, http.StatusCreated, struct{}{})
+	case "PATCH projects/columns/cards/*":
+		var opts github.ProjectCardOptions
+		json.Unmarshal(body, &opts)
+		card := f.card(parts[3])
+		if card == nil {
+			writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if opts.Archived != nil {
+			card.Archived = *opts.Archived
+		}
+		if opts.Note != "" {
+			card.Note = opts.Note
+		}
+		card.UpdatedAt = time.Now()
+		writeFakeJSON(w, http.StatusOK, card)
+	case "DELETE projects/columns/cards/*":
+		for i, card := range f.cards {
+			if strconv.FormatInt(card.ID, 10) == parts[3] {
+				f.cards = append(f.cards[:i], f.cards[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case "GET repos/*/*/pulls":
+		prs := []*github.PullRequest{}
+		for _, pr := range f.pulls {
+			if f.inRepo(pr, parts) && pr.GetState() == "open" {
+				prs = append(prs, pr)
+			}
+		}
+		writeFakePage(w, req, prs)
+	case "GET repos/*/*/commits/*/pulls":
+		prs := []*github.PullRequest{}
+		for _, pr := range f.pulls {
+			if f.inRepo(pr, parts) && pr.GetHead().GetSHA() == parts[4] {
+				prs = append(prs, pr)
+			}
+		}
+		writeFakePage(w, req, prs)
+	case "GET repos/*/*/pulls/*":
+		if pr := f.pull(parts); pr != nil {
+			writeFakeJSON(w, http.StatusOK, pr)
+			return
+		}
+		writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case "GET repos/*/*/issues":
+		// Only the pull requests are listed, filtered by milestone number and state.
+		issues := []*github.Issue{}
+		q := req.URL.Query()
+		for _, pr := range f.pulls {
+			if !f.inRepo(pr, parts) || q.Get("state") != "" && q.Get("state") != "all" && pr.GetState() != q.Get("state") {
+				continue
+			}
+			if m := q.Get("milestone"); m != "" && strconv.Itoa(pr.GetMilestone().GetNumber()) != m {
+				continue
+			}
+			issues = append(issues, &github.Issue{Number: pr.Number, Title: pr.Title, State: pr.State, PullRequestLinks: &github.PullRequestLinks{URL: pr.URL}})
+		}
+		writeFakePage(w, req, issues)
+	case "GET repos/*/*/issues/*":
+		pr := f.pull(parts)
+		if pr == nil {
+			writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		var labels []github.Label
+		for _, l := range pr.Labels {
+			labels = append(labels, *l)
+		}
+		writeFakeJSON(w, http.StatusOK, &github.Issue{
+			Number:           pr.Number,
+			Title:            pr.Title,
+			State:            pr.State,
+			Labels:           labels,
+			URL:              pr.IssueURL,
+			PullRequestLinks: &github.PullRequestLinks{URL: pr.URL},
+		})
+	case "GET repos/*/*/pulls/*/reviews":
+		reviews := append([]*github.PullRequestReview{}, f.reviews[parts[1]+"/"+parts[2]+"#"+parts[4]]...)
+		writeFakePage(w, req, reviews)
+	case "GET repos/*/*/issues/*/comments":
+		comments := []*github.IssueComment{}
+		for _, body := range f.comments[parts[1]+"/"+parts[2]+"#"+parts[4]] {
+			comments = append(comments, &github.IssueComment{Body: github.String(body)})
+		}
+		writeFakePage(w, req, comments)
+	case "POST repos/*/*/issues/*/comments":
+		var comment github.IssueComment
+		json.Unmarshal(body, &comment)
+		key := parts[1] + "/" + parts[2] + "#" + parts[4]
+		f.comments[key] = append(f.comments[key], comment.GetBody())
+		writeFakeJSON(w, http.StatusCreated, comment)
+	default:
+		writeFakeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found", "route": route})
+	}
+}
+
+// routePattern returns the path of a request with its IDs, owners and names replaced with "*".
+// Method-specific routes such as "projects/columns/cards" keep their fixed segments.
+func routePattern(parts []string) string {
+	fixed := map[string]bool{
+		"repos": true, "orgs": true, "projects": true, "columns": true, "cards": true, "moves": true,
+		"pulls": true, "issues": true, "comments": true, "reviews": true, "commits": true, "rate_limit": true,
+	}
+	pattern := make([]string, len(parts))
+	for i, part := range parts {
+		pattern[i] = part
+		// Owners and names come right after repos and orgs, whatever they are.
+		if !fixed[part] || i > 0 && (parts[i-1] == "repos" || parts[i-1] == "orgs") || i > 1 && parts[i-2] == "repos" {
+			pattern[i] = "*"
+		}
+	}
+	return strings.Join(pattern, "/")
+}
+
+// pull returns the pull request of the repos/OWNER/REPO/pulls/N path, or nil.
+func (f *fakeGitHub) pull(parts []string) *github.PullRequest {
+	for _, pr := range f.pulls {
+		if f.inRepo(pr, parts) && strconv.Itoa(pr.GetNumber()) == parts[4] {
+			return pr
+		}
+	}
+	return nil
+}
+
+// inRepo returns true if the pull request is in the repository of the repos/OWNER/REPO path.
+func (f *fakeGitHub) inRepo(pr *github.PullRequest, parts []string) bool {
+	return strings.EqualFold(pr.GetBase().GetRepo().GetFullName(), parts[1]+"/"+parts[2])
+}
+
+func (f *fakeGitHub) card(id string) *fakeCard {
+	for _, card := range f.cards {
+		if strconv.FormatInt(card.ID, 10) == id {
+			return card
+		}
+	}
+	return nil
+}
+
+func (f *fakeGitHub) projectOf(column int64) int64 {
+	for _, c := range f.columns {
+		if c.ID == column {
+			return c.project
+		}
+	}
+	return 0
+}
+
+// positionCard moves the card within its column to the position, "top", "bottom" or "after:<card-id>".
+func (f *fakeGitHub) positionCard(card *fakeCard, position string) {
+	var rest []*fakeCard
+	for _, c := range f.cards {
+		if c != card {
+			rest = append(rest, c)
+		}
+	}
+	index := len(rest)
+	switch {
+	case position == "top":
+		for i, c := range rest {
+			if c.column == card.column {
+				index = i
+				break
+			}
+		}
+	case strings.HasPrefix(position, "after:"):
+		for i, c := range rest {
+			if strconv.FormatInt(c.ID, 10) == strings.TrimPrefix(position, "after:") {
+				index = i + 1
+			}
+		}
+	}
+	f.cards = append(rest[:index], append([]*fakeCard{card}, rest[index:]...)...)
+}
+
+// moveColumn moves the column to the position, "first", "last" or "after:<column-id>".
+func (f *fakeGitHub) moveColumn(id, position string) {
+	var moved *fakeColumn
+	var rest []*fakeColumn
+	for _, column := range f.columns {
+		if strconv.FormatInt(column.ID, 10) == id {
+			moved = column
+			continue
+		}
+		rest = append(rest, column)
+	}
+	if moved == nil {
+		return
+	}
+	index := len(rest)
+	switch {
+	case position == "first":
+		for i, column := range rest {
+			if column.project == moved.project {
+				index = i
+				break
+			}
+		}
+	case strings.HasPrefix(position, "after:"):
+		for i, column := range rest {
+			if strconv.FormatInt(column.ID, 10) == strings.TrimPrefix(position, "after:") {
+				index = i + 1
+			}
+		}
+	}
+	f.columns = append(rest[:index], append([]*fakeColumn{moved}, rest[index:]...)...)
+}
+
+// writeFakePage replies with the page of the items requested with the page and per_page parameters,
+// linking to the next page like GitHub does.
+func writeFakePage(w http.ResponseWriter, req *http.Request, items interface{}) {
+	v := reflect.ValueOf(items)
+	perPage, err := strconv.Atoi(req.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 30
+	}
+	page, err := strconv.Atoi(req.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	start, end := (page-1)*perPage, page*perPage
+	if start > v.Len() {
+		start = v.Len()
+	}
+	if end > v.Len() {
+		end = v.Len()
+	} else if end < v.Len() {
+		next := *req.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, req.Host, next.RequestURI()))
+	}
+	writeFakeJSON(w, http.StatusOK, v.Slice(start, end).Interface())
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// testConfig loads the configuration from the environment variables, on top of a webhook secret.
+func testConfig(t *testing.T, env map[string]string) *config {
+	t.Helper()
+	cfg, err := loadTestConfig(env)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	return cfg
+}
+
+// loadTestConfig loads the configuration from the environment variables, on top of a webhook secret.
+// The variables are only set while the configuration is loaded.
+func loadTestConfig(env map[string]string) (*config, error) {
+	vars := map[string]string{"WEBHOOK_SECRET": testSecret}
+	for k, v := range env {
+		vars[k] = v
+	}
+	for k, v := range vars {
+		prev, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		if ok {
+			defer os.Setenv(k, prev)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
+	return loadConfig()
+}
+
+// newTestServer returns a server calling the fake GitHub API, configured with the environment variables.
+func newTestServer(t *testing.T, f *fakeGitHub, env map[string]string) *server {
+	t.Helper()
+	cfg := testConfig(t, env)
+	cfg.apiURL = f.url()
+	return newServer(cfg)
+}
+
+// sendWebhook delivers the event to the server, signed with the test secret, and returns the response.
+// The payload is sent as it is if it's a string, and encoded as JSON otherwise.
+func sendWebhook(t *testing.T, s *server, eventType string, payload interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	body, ok := payload.(string)
+	if !ok {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("encode %s payload: %v", eventType, err)
+		}
+		body = string(b)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/projectbot", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", fmt.Sprintf("delivery-%d", time.Now().UnixNano()))
+	req.Header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, body))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sign(sha256.New, body))
+	rec := httptest.NewRecorder()
+	newHandler(s).ServeHTTP(rec, req)
+	return rec
+}
+
+func sign(h func() hash.Hash, body string) string {
+	mac := hmac.New(h, []byte(testSecret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// prEvent returns a pull_request event of the action on the pull request.
+func prEvent(action string, pr *github.PullRequest) *github.PullRequestEvent {
+	return &github.PullRequestEvent{
+		Action:      github.String(action),
+		Number:      pr.Number,
+		PullRequest: pr,
+		Repo:        pr.GetBase().GetRepo(),
+		Sender:      &github.User{Login: github.String("octocat")},
+	}
+}
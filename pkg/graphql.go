@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// graphqlRequest is the body of a GraphQL API request.
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphqlError is an error reported in a GraphQL API response.
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+// graphql runs the query against the GraphQL API and decodes its data into v.
+func graphql(ctx context.Context, client *github.Client, query string, variables map[string]interface{}, v interface{}) error {
+	// GitHub Enterprise serves the GraphQL API at /api/graphql next to the REST API at /api/v3.
+	endpoint := "graphql"
+	if strings.HasSuffix(client.BaseURL.Path, "/api/v3/") {
+		endpoint = "../graphql"
+	}
+	req, err := client.NewRequest(http.MethodPost, endpoint, &graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	resp := struct {
+		Data   interface{}    `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}{Data: v}
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		var msgs []string
+		for _, e := range resp.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// projectNodeQuery resolves the global node ID of a project board to its REST API ID.
+const projectNodeQuery = `query($id: ID!) { node(id: $id) { ... on Project { databaseId } } }`
+
+// getProjectByID returns the project board with the ID, either its REST API ID or its global node ID.
+func getProjectByID(ctx context.Context, client *github.Client, id string) (*github.Project, error) {
+	databaseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		var data struct {
+			Node *struct {
+				DatabaseID int64 `json:"databaseId"`
+			} `json:"node"`
+		}
+		if err := graphql(ctx, client, projectNodeQuery, map[string]interface{}{"id": id}, &data); err != nil {
+			return nil, fmt.Errorf("resolve project node %s: %w", id, err)
+		}
+		if data.Node == nil || data.Node.DatabaseID == 0 {
+			return nil, &missingProjectError{
+				status: http.StatusNotFound,
+				msg:    fmt.Sprintf("project with id %s not found", id),
+			}
+		}
+		databaseID = data.Node.DatabaseID
+	}
+	proj, resp, err := client.Projects.GetProject(ctx, databaseID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, &missingProjectError{
+				status: http.StatusNotFound,
+				msg:    fmt.Sprintf("project with id %s not found", id),
+			}
+		}
+		return nil, fmt.Errorf("get project %s: %w", id, err)
+	}
+	return proj, nil
+}
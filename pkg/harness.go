@@ -0,0 +1,472 @@
+//go:build harness
+// +build harness
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The harness serves a mock GitHub API alongside the bot so that webhooks can be exercised locally.
+// It is only compiled with the "harness" build tag:
+//
+//	go build -tags harness -o project-bot-harness ./pkg && ./project-bot-harness harness
+func init() {
+	subcommands["harness"] = runHarness
+	// Webhooks sent by the harness are signed with a throwaway secret unless one is provided.
+	if os.Getenv("WEBHOOK_SECRET") == "" {
+		os.Setenv("WEBHOOK_SECRET", "harness")
+	}
+}
+
+// sampleWebhook returns an "opened" event for the n-th sample pull request.
+func sampleWebhook(n int) string {
+	return fmt.Sprintf(`{
+  "action": "opened",
+  "number": %[1]d,
+  "pull_request": {
+    "id": %[2]d,
+    "node_id": "sample-pr-%[1]d",
+    "number": %[1]d,
+    "title": "Sample feature %[1]d",
+    "state": "open",
+    "html_url": "https://github.com/%[3]s/%[4]s/pull/%[1]d",
+    "issue_url": "https://api.github.com/repos/%[3]s/%[4]s/issues/%[1]d",
+    "user": {"login": "octocat"},
+    "head": {"sha": "sample-sha-%[1]d"}
+  },
+  "repository": {
+    "name": "%[4]s",
+    "full_name": "%[3]s/%[4]s",
+    "owner": {"login": "%[3]s"}
+  },
+  "sender": {"login": "octocat"}
+}`, n, 1000+n, OWNER, REPO)
+}
+
+func runHarness(cfg *config) error {
+	var columns []string
+	for _, column := range cfg.columns {
+		columns = append(columns, cfg.columnTitle(column))
+	}
+	if v := os.Getenv("HARNESS_COLUMNS"); v != "" {
+		columns = strings.Split(v, ",")
+	}
+	var err error
+	mock := newMockGitHub(cfg.projectName, columns)
+	// HARNESS_CONFLICTS rejects the first card changes with 409 Conflict to exercise retries.
+	if mock.conflicts, err = envInt("HARNESS_CONFLICTS", 0); err != nil {
+		return err
+	}
+	// HARNESS_UNLINKABLE rejects cards linked to pull requests with 422 to exercise note cards.
+	if mock.unlinkable, err = envBool("HARNESS_UNLINKABLE", false); err != nil {
+		return err
+	}
+	// HARNESS_INVALID_TOKEN rejects every request with 401 Unauthorized to exercise the startup token check.
+	if mock.invalidToken, err = envBool("HARNESS_INVALID_TOKEN", false); err != nil {
+		return err
+	}
+	api := httptest.NewServer(mock)
+	defer api.Close()
+	apiURL, err := url.Parse(api.URL + "/")
+	if err != nil {
+		return err
+	}
+	cfg.apiURL = apiURL
+
+	addr := os.Getenv("HARNESS_ADDR")
+	if addr == "" {
+		addr = "localhost:8080"
+	}
+	s := newServer(cfg)
+	if err := s.checkBoard(context.Background()); err != nil {
+		return err
+	}
+	if cfg.pollInterval > 0 {
+		go s.poll(cfg.pollInterval)
+	}
+	if cfg.cardMetricsInterval > 0 {
+		go s.observeCards(cfg.cardMetricsInterval)
+	}
+	if cfg.staleInterval > 0 {
+		go s.sweepStale(cfg.staleInterval)
+	}
+	if cfg.archiveAfter > 0 {
+		go s.archiveCards(archiveInterval(cfg.archiveAfter))
+	}
+	bot := &http.Server{Addr: addr, Handler: newHandler(s)}
+	errs := make(chan error, 1)
+	go func() { errs <- bot.ListenAndServe() }()
+
+	log.Printf("🧪 mock GitHub API at %s, board state at %s/harness/board\n", api.URL, api.URL)
+	log.Printf("🧪 bot listening at http://%s/api/projectbot\n", addr)
+
+	if err := sendSampleWebhook("http://"+addr+"/api/projectbot", 1); err != nil {
+		log.Printf("🚨 error sending sample webhook: err=%s\n", err)
+	}
+	// Queued events are processed after their delivery was answered.
+	for len(s.queue) > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cfg.asyncWorkers > 0 {
+		time.Sleep(100 * time.Millisecond)
+	}
+	// Opened pull requests are placed after their grace period.
+	if cfg.openedGracePeriod > 0 {
+		time.Sleep(cfg.openedGracePeriod + 100*time.Millisecond)
+	}
+	if missing := mock.missingCards(1000+1, 1000+1); len(missing) > 0 {
+		log.Printf("🚨 error no card was created for pull requests %v\n", missing)
+	}
+	mock.logBoard()
+	return <-errs
+}
+
+// sendSampleWebhook posts the n-th sample webhook to the bot, signed with WEBHOOK_SECRET.
+func sendSampleWebhook(endpoint string, n int) error {
+	body := sampleWebhook(n)
+	mac := hmac.New(sha1.New, []byte(os.Getenv("WEBHOOK_SECRET")))
+	mac.Write([]byte(body))
+	signature := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		req, _ := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		req.Header.Set("X-GitHub-Delivery", fmt.Sprintf("harness-%d", n))
+		req.Header.Set("X-Hub-Signature", signature)
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+		// The bot might not be listening yet.
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	log.Printf("🧪 sample webhook %d answered %s\n", n, resp.Status)
+	return nil
+}
+
+type mockColumn struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type mockCard struct {
+	ID          int64  `json:"id"`
+	NodeID      string `json:"node_id"`
+	ColumnID    int64  `json:"column_id"`
+	ContentID   int64  `json:"content_id,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	ContentURL  string `json:"content_url,omitempty"`
+	Note        string `json:"note,omitempty"`
+	Archived    bool   `json:"archived"`
+}
+
+// mockGitHub is an in-memory implementation of the project endpoints used by the bot.
+type mockGitHub struct {
+	mu           sync.Mutex
+	projectName  string
+	columns      []*mockColumn
+	cards        []*mockCard
+	nextID       int64
+	conflicts    int
+	unlinkable   bool
+	invalidToken bool
+}
+
+func newMockGitHub(projectName string, columnNames []string) *mockGitHub {
+	m := &mockGitHub{projectName: projectName, nextID: 100}
+	for i, name := range columnNames {
+		m.columns = append(m.columns, &mockColumn{ID: int64(i + 1), Name: name})
+	}
+	return m
+}
+
+func (m *mockGitHub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if m.invalidToken && parts[0] != "harness" {
+		writeMockJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+	switch {
+	case req.Method == http.MethodGet && len(parts) == 1 && parts[0] == "rate_limit":
+		writeMockJSON(w, http.StatusOK, map[string]interface{}{
+			"resources": map[string]interface{}{"core": map[string]int{"limit": 5000, "remaining": 5000}},
+		})
+	case req.Method == http.MethodGet && len(parts) == 2 && parts[0] == "harness" && parts[1] == "board":
+		writeMockJSON(w, http.StatusOK, map[string]interface{}{"columns": m.columns, "cards": m.cards})
+	case req.Method == http.MethodGet && len(parts) == 4 && parts[0] == "repos" && parts[3] == "projects",
+		req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "orgs" && parts[2] == "projects":
+		writeMockJSON(w, http.StatusOK, []map[string]interface{}{
+			{"id": 1, "number": 1, "name": m.projectName, "state": "open"},
+		})
+	case req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "projects" && parts[2] == "columns":
+		writeMockJSON(w, http.StatusOK, m.columns)
+	case req.Method == http.MethodPost && len(parts) == 3 && parts[0] == "projects" && parts[2] == "columns":
+		var opts struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(req.Body).Decode(&opts)
+		m.nextID++
+		column := &mockColumn{ID: m.nextID, Name: opts.Name}
+		m.columns = append(m.columns, column)
+		log.Printf("🧪 mock created column %d %s\n", column.ID, column.Name)
+		writeMockJSON(w, http.StatusCreated, column)
+	case req.Method == http.MethodPost && len(parts) == 4 && parts[0] == "projects" && parts[1] == "columns" && parts[3] == "moves":
+		columnID, _ := strconv.ParseInt(parts[2], 10, 64)
+		var opts struct {
+			Position string `json:"position"`
+		}
+		json.NewDecoder(req.Body).Decode(&opts)
+		m.moveColumn(columnID, opts.Position)
+		writeMockJSON(w, http.StatusCreated, struct{}{})
+	case len(parts) == 4 && parts[0] == "projects" && parts[1] == "columns" && parts[2] == "cards" &&
+		(req.Method == http.MethodPatch || req.Method == http.MethodDelete):
+		cardID, _ := strconv.ParseInt(parts[3], 10, 64)
+		for i, card := range m.cards {
+			if card.ID != cardID {
+				continue
+			}
+			if req.Method == http.MethodDelete {
+				m.cards = append(m.cards[:i], m.cards[i+1:]...)
+				log.Printf("🧪 mock deleted card %d\n", card.ID)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			var opts struct {
+				Archived *bool `json:"archived"`
+			}
+			json.NewDecoder(req.Body).Decode(&opts)
+			if opts.Archived != nil {
+				card.Archived = *opts.Archived
+				log.Printf("🧪 mock set card %d archived=%t\n", card.ID, card.Archived)
+			}
+			writeMockJSON(w, http.StatusOK, card)
+			return
+		}
+		writeMockJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case len(parts) == 4 && parts[0] == "projects" && parts[1] == "columns" && parts[3] == "cards":
+		columnID, _ := strconv.ParseInt(parts[2], 10, 64)
+		if req.Method == http.MethodGet {
+			state := req.URL.Query().Get("archived_state")
+			cards := []*mockCard{}
+			for _, card := range m.cards {
+				if card.ColumnID == columnID && (state == "all" || card.Archived == (state == "archived")) {
+					cards = append(cards, card)
+				}
+			}
+			writeMockJSON(w, http.StatusOK, cards)
+			return
+		}
+		if m.conflict(w) {
+			return
+		}
+		var opts struct {
+			Note        string `json:"note"`
+			ContentID   int64  `json:"content_id"`
+			ContentType string `json:"content_type"`
+		}
+		json.NewDecoder(req.Body).Decode(&opts)
+		if m.unlinkable && opts.ContentID != 0 {
+			writeMockJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+			return
+		}
+		// Like GitHub, content can only be on the project once, even when its card is archived.
+		for _, card := range m.cards {
+			if opts.ContentID != 0 && card.ContentID == opts.ContentID {
+				writeMockJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+					"message": "Validation Failed",
+					"errors":  []map[string]string{{"message": "Project already has the associated issue"}},
+				})
+				return
+			}
+		}
+		m.nextID++
+		card := &mockCard{
+			ID:          m.nextID,
+			NodeID:      fmt.Sprintf("card-%d", m.nextID),
+			ColumnID:    columnID,
+			ContentID:   opts.ContentID,
+			ContentType: opts.ContentType,
+			Note:        opts.Note,
+		}
+		// Sample pull requests link to their issue, like on GitHub.
+		if opts.ContentID != 0 {
+			card.ContentURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d", OWNER, REPO, opts.ContentID-1000)
+		}
+		m.cards = append(m.cards, card)
+		log.Printf("🧪 mock created card %d in column %d\n", card.ID, columnID)
+		writeMockJSON(w, http.StatusCreated, card)
+	case req.Method == http.MethodPost && len(parts) == 5 && parts[0] == "projects" && parts[2] == "cards" && parts[4] == "moves":
+		if m.conflict(w) {
+			return
+		}
+		cardID, _ := strconv.ParseInt(parts[3], 10, 64)
+		var opts struct {
+			ColumnID int64 `json:"column_id"`
+		}
+		json.NewDecoder(req.Body).Decode(&opts)
+		for _, card := range m.cards {
+			if card.ID == cardID {
+				if opts.ColumnID != 0 {
+					card.ColumnID = opts.ColumnID
+				}
+				log.Printf("🧪 mock moved card %d to column %d\n", card.ID, card.ColumnID)
+				writeMockJSON(w, http.StatusCreated, struct{}{})
+				return
+			}
+		}
+		writeMockJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case req.Method == http.MethodGet && len(parts) == 4 && parts[0] == "repos" && parts[3] == "pulls":
+		// The open pull requests never change, so conditional requests are always answered 304.
+		const etag = `"harness-pulls"`
+		if req.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		var prs []map[string]interface{}
+		for n := 1; n <= 3; n++ {
+			var pr map[string]interface{}
+			json.Unmarshal([]byte(sampleWebhook(n)), &struct {
+				PR *map[string]interface{} `json:"pull_request"`
+			}{&pr})
+			prs = append(prs, pr)
+		}
+		w.Header().Set("ETag", etag)
+		writeMockJSON(w, http.StatusOK, prs)
+	case req.Method == http.MethodGet && len(parts) == 6 && parts[0] == "repos" && parts[3] == "commits" && parts[5] == "pulls":
+		// Sample pull requests have the head commit sample-sha-N.
+		prs := []map[string]interface{}{}
+		var n int
+		if _, err := fmt.Sscanf(parts[4], "sample-sha-%d", &n); err == nil {
+			var pr map[string]interface{}
+			json.Unmarshal([]byte(sampleWebhook(n)), &struct {
+				PR *map[string]interface{} `json:"pull_request"`
+			}{&pr})
+			prs = append(prs, pr)
+		}
+		writeMockJSON(w, http.StatusOK, prs)
+	case req.Method == http.MethodGet && len(parts) == 5 && parts[0] == "repos" && parts[3] == "pulls":
+		n, _ := strconv.Atoi(parts[4])
+		var pr map[string]interface{}
+		json.Unmarshal([]byte(sampleWebhook(n)), &struct {
+			PR *map[string]interface{} `json:"pull_request"`
+		}{&pr})
+		writeMockJSON(w, http.StatusOK, pr)
+	case req.Method == http.MethodPost && len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "comments":
+		var comment struct {
+			Body string `json:"body"`
+		}
+		json.NewDecoder(req.Body).Decode(&comment)
+		log.Printf("🧪 mock commented on #%s: %s\n", parts[4], comment.Body)
+		writeMockJSON(w, http.StatusCreated, comment)
+	case req.Method == http.MethodGet && len(parts) == 6 && parts[0] == "repos" && parts[3] == "collaborators" && parts[5] == "permission":
+		writeMockJSON(w, http.StatusOK, map[string]string{"permission": os.Getenv("HARNESS_PERMISSION")})
+	default:
+		log.Printf("🧪 mock has no route for %s %s\n", req.Method, req.URL.Path)
+		writeMockJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+// conflict replies 409 Conflict while there are conflicts left to simulate.
+func (m *mockGitHub) conflict(w http.ResponseWriter) bool {
+	if m.conflicts == 0 {
+		return false
+	}
+	m.conflicts--
+	log.Println("🧪 mock simulated a conflict")
+	writeMockJSON(w, http.StatusConflict, map[string]string{"message": "Conflict"})
+	return true
+}
+
+// moveColumn moves the column to the position, one of "first", "last" or "after:<column-id>".
+func (m *mockGitHub) moveColumn(id int64, position string) {
+	var moved *mockColumn
+	var rest []*mockColumn
+	for _, column := range m.columns {
+		if column.ID == id {
+			moved = column
+			continue
+		}
+		rest = append(rest, column)
+	}
+	if moved == nil {
+		return
+	}
+	index := len(rest)
+	if position == "first" {
+		index = 0
+	}
+	if strings.HasPrefix(position, "after:") {
+		after, _ := strconv.ParseInt(strings.TrimPrefix(position, "after:"), 10, 64)
+		for i, column := range rest {
+			if column.ID == after {
+				index = i + 1
+			}
+		}
+	}
+	m.columns = append(rest[:index], append([]*mockColumn{moved}, rest[index:]...)...)
+}
+
+// missingCards returns the pull request IDs between from and to that have no card on the board.
+func (m *mockGitHub) missingCards(from, to int64) []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var missing []int64
+	for id := from; id <= to; id++ {
+		found := false
+		for _, card := range m.cards {
+			if card.ContentID == id || strings.HasSuffix(card.Note, fmt.Sprintf("/pull/%d", id-1000)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// logBoard prints the cards in each column of the mock board.
+func (m *mockGitHub) logBoard() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, column := range m.columns {
+		var ids []string
+		for _, card := range m.cards {
+			if card.ColumnID == column.ID {
+				ids = append(ids, strconv.FormatInt(card.ID, 10))
+			}
+		}
+		log.Printf("🧪 %s: [%s]\n", column.Name, strings.Join(ids, ", "))
+	}
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
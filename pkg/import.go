@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// The import command adds cards for the open pull requests that don't have one yet, placed by their current state,
+// such as when onboarding a repository whose pull requests were opened before the bot:
+//
+//	project-bot import
+//
+// Cards already on the board are left where they are.
+func init() {
+	subcommands["import"] = importPullRequests
+}
+
+// minImportRateLimit is how many GitHub API requests must be left to go on importing,
+// the import waits for the rate limit to reset below it.
+const minImportRateLimit = 100
+
+// importCheckEvery is how many pull requests are imported between rate limit checks.
+const importCheckEvery = 50
+
+// importPullRequests creates the missing cards of the open pull requests of the home repository.
+func importPullRequests(cfg *config) error {
+	ctx := context.Background()
+	// Hundreds of imported cards would flood the chat, they're only logged.
+	cfg.notifiers = []notifier{logNotifier{}}
+	s := newServer(cfg)
+	repo := homeRepository()
+	prs, err := listOpenPullRequests(ctx, s.client, repo.GetOwner().GetLogin(), repo.GetName())
+	if err != nil {
+		return err
+	}
+	boards, err := s.repoBoards(ctx, repo)
+	if err != nil {
+		return err
+	}
+	ctx = withCardSnapshot(ctx)
+	imported, present := 0, 0
+	for i, pr := range prs {
+		if i%importCheckEvery == 0 {
+			if err := s.waitForRateLimit(ctx, minImportRateLimit); err != nil {
+				return err
+			}
+		}
+		if !cfg.isTrackedBranch(pr.GetBase().GetRef()) {
+			continue
+		}
+		p := s.polledPlacement(pr)
+		p.create, p.keep = true, true
+		for _, b := range boards {
+			_, o, err := s.placeCard(ctx, b, pr, p)
+			if err != nil {
+				return fmt.Errorf("import card for pr %s on project %s: %w", pr.GetTitle(), b.project.GetName(), err)
+			}
+			if o == created {
+				imported++
+			} else {
+				present++
+			}
+		}
+	}
+	fmt.Printf("imported %d cards for %d open pull requests, %d were already on the board\n", imported, len(prs), present)
+	return nil
+}
+
+// waitForRateLimit waits until the rate limit resets if fewer than min GitHub API requests are left.
+func (s *server) waitForRateLimit(ctx context.Context, min int) error {
+	limits, _, err := s.client.RateLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("get rate limits: %w", err)
+	}
+	core := limits.GetCore()
+	if core.Remaining >= min {
+		return nil
+	}
+	log.Printf("⏸️ only %d GitHub API requests left, waiting until %s\n", core.Remaining, core.Reset)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(core.Reset.Time)):
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Importing creates the missing cards of all the open pull requests, over several pages,
+// and leaves the cards already on the board where they are.
+func TestImportPullRequests(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	cfg := testConfig(t, nil)
+	cfg.apiURL = f.url()
+	const open = 150
+	for n := 1; n <= open; n++ {
+		f.pr(n)
+	}
+	done := f.addCard(f.column(PENDING_RELEASE), f.newPR(1))
+	closed := f.newPR(open + 1)
+	closed.State = github.String("closed")
+	f.addPR(closed)
+
+	if err := importPullRequests(cfg); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if got := len(f.cardsIn(f.column(IN_REVIEW))); got != open-1 {
+		t.Errorf("got %d cards in %s, want %d", got, IN_REVIEW, open-1)
+	}
+	if card := f.cardOf(f.newPR(1)); card == nil || card.ID != done.ID || card.column != f.column(PENDING_RELEASE) {
+		t.Errorf("got card %v, want card %d left in %s", card, done.ID, PENDING_RELEASE)
+	}
+	if card := f.cardOf(closed); card != nil {
+		t.Errorf("got card %v for the closed pull request, want none", card)
+	}
+	checks := 0
+	for _, r := range f.requests() {
+		if r.path == "/rate_limit" {
+			checks++
+		}
+	}
+	if want := (open + importCheckEvery - 1) / importCheckEvery; checks != want {
+		t.Errorf("got %d rate limit checks, want %d", checks, want)
+	}
+}
+
+// Importing waits for the rate limit to reset when too few requests are left.
+func TestWaitForRateLimit(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	if err := s.waitForRateLimit(context.Background(), minImportRateLimit); err != nil {
+		t.Errorf("enough requests left: got error %v, want none", err)
+	}
+
+	f.mu.Lock()
+	f.remaining = minImportRateLimit - 1
+	f.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.waitForRateLimit(ctx, minImportRateLimit); err != context.DeadlineExceeded {
+		t.Errorf("too few requests left: got error %v, want to wait until the reset", err)
+	}
+}
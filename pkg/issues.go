@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+const (
+	// transferArchive archives the cards of transferred issues.
+	transferArchive = "archive"
+	// transferDelete deletes the cards of transferred issues.
+	transferDelete = "delete"
+)
+
+// issueActions are the actions of issues events whose cards can be placed with ISSUE_COLUMNS.
+// The cards of issues are only created when they're opened or reopened, closing an issue moves its card.
+var issueActions = []string{"opened", "reopened", "closed"}
+
+// forIssues returns a copy of the configuration for the board of issues, whose logical columns are
+// the columns of ISSUE_COLUMNS, on the ISSUE_PROJECT_NAME board when it's set.
+// Issues aren't sorted into column sets nor fanned out to the extra boards.
+func (cfg *config) forIssues() *config {
+	c := *cfg
+	if cfg.issueProject != "" {
+		c.projectName = cfg.issueProject
+		c.projectNumber = 0
+		c.projectID = ""
+	}
+	c.columns = nil
+	for _, action := range issueActions {
+		if title, ok := cfg.issueColumns[action]; ok && !contains(c.columns, title) {
+			c.columns = append(c.columns, title)
+		}
+	}
+	c.scanColumns = c.columns
+	c.optionalColumns = nil
+	c.columnTitles = make(map[string]string)
+	c.columnPatterns = make(map[string]*regexp.Regexp)
+	c.columnSets = nil
+	c.extraProjects = nil
+	return &c
+}
+
+// handleIssues places the cards of issues in the columns their action maps to, and cleans up
+// the cards of issues transferred to another repository, which link to an issue that no longer exists here.
+func (s *server) handleIssues(ctx context.Context, w http.ResponseWriter, e *github.IssuesEvent) {
+	column, placed := s.cfg.issueColumns[e.GetAction()]
+	if !placed && (e.GetAction() != "transferred" || s.cfg.issueTransferredAction == "") {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	repo, number, ok := parseContentURL(e.GetIssue().GetURL())
+	if !ok {
+		log.Printf("🚨 error issue %s has an unexpected URL %s\n", e.GetIssue().GetTitle(), e.GetIssue().GetURL())
+		http.Error(w, "event has no issue URL", http.StatusBadRequest)
+		return
+	}
+	// Transferred issues may have cards on the board of pull requests as well as on the board of issues.
+	cfg := s.configFor(ctx, e.GetRepo())
+	var cfgs []*config
+	if !placed {
+		cfgs = append(cfgs, cfg)
+	}
+	if len(s.cfg.issueColumns) > 0 {
+		cfgs = append(cfgs, cfg.forIssues())
+	}
+	boards := make([][]*board, len(cfgs))
+	for i, c := range cfgs {
+		b, err := resolveBoards(ctx, s.client, c, e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName())
+		if err != nil {
+			if repo, ok := s.cfg.skipsBoardError(err); ok {
+				s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping events of %s\n", err, repo)
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			s.fail(ctx, w, "getting project board", err)
+			return
+		}
+		boards[i] = b
+	}
+	if placed {
+		best := skipped
+		for _, b := range boards[0] {
+			o, err := s.placeIssueCard(ctx, b, e.GetIssue(), column, e.GetAction() != "closed")
+			if err != nil {
+				s.fail(ctx, w, fmt.Sprintf("placing card of issue %s", e.GetIssue().GetTitle()), err)
+				return
+			}
+			if o > best {
+				best = o
+			}
+		}
+		w.WriteHeader(s.cfg.statusCode(best))
+		return
+	}
+	cleaned := 0
+	for i, c := range cfgs {
+		for _, b := range boards[i] {
+			n, err := s.cleanUpIssueCards(ctx, b, c.scanColumns, repo, number)
+			if err != nil {
+				s.fail(ctx, w, fmt.Sprintf("cleaning up card of transferred issue %s", e.GetIssue().GetTitle()), err)
+				return
+			}
+			cleaned += n
+		}
+	}
+	if cleaned == 0 {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	log.Printf("📦 issue %s was transferred, cleaned up %d cards\n", e.GetIssue().GetTitle(), cleaned)
+	w.WriteHeader(http.StatusOK)
+}
+
+// placeIssueCard moves the card of the issue on the board of issues to the column, or adds it there if create is true.
+// Cards of issues that were archived by hand are left alone.
+func (s *server) placeIssueCard(ctx context.Context, b *board, issue *github.Issue, column string, create bool) (outcome, error) {
+	card, current, err := s.findIssueCard(ctx, b, issue)
+	if err != nil {
+		return skipped, err
+	}
+	switch {
+	case card != nil && current == column:
+		log.Printf("🤷‍♀️ card for issue %s is already in column %s, no change\n", issue.GetTitle(), column)
+		return unchanged, nil
+	case card != nil:
+		if _, err := s.client.Projects.MoveProjectCard(ctx, card.GetID(), &github.ProjectCardMoveOptions{
+			Position: "bottom",
+			ColumnID: b.columns[column].GetID(),
+		}); err != nil {
+			return skipped, fmt.Errorf("move project card for issue %s: %w", issue.GetTitle(), err)
+		}
+		cardsMoved.inc()
+		s.logIssueChange(ctx, b, card, issue, "moved", column)
+		return moved, nil
+	case !create:
+		return skipped, nil
+	}
+	card, _, err = s.client.Projects.CreateProjectCard(ctx, b.columns[column].GetID(), &github.ProjectCardOptions{
+		ContentID:   issue.GetID(),
+		ContentType: "Issue",
+	})
+	if isAlreadyOnProject(err) {
+		log.Printf("🤷‍♀️ card for issue %s is archived, skipping\n", issue.GetTitle())
+		return skipped, nil
+	}
+	if err != nil {
+		return skipped, fmt.Errorf("create project card for issue %s: %w", issue.GetTitle(), err)
+	}
+	cardsCreated.inc()
+	s.logIssueChange(ctx, b, card, issue, "created", column)
+	return created, nil
+}
+
+// findIssueCard returns the card of the issue on the board of issues along with its column,
+// or nil if the issue isn't on the board or was archived.
+func (s *server) findIssueCard(ctx context.Context, b *board, issue *github.Issue) (*github.ProjectCard, string, error) {
+	repo, number, _ := parseContentURL(issue.GetURL())
+	for column := range b.columns {
+		cards, err := s.listCards(ctx, b, column)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, card := range cards {
+			cardRepo, cardNumber, ok := parseContentURL(card.GetContentURL())
+			if ok && cardNumber == number && strings.EqualFold(cardRepo, repo) {
+				return card, column, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// logIssueChange logs a change the bot made to the card of the issue, and remembers it as the bot's.
+func (s *server) logIssueChange(ctx context.Context, b *board, card *github.ProjectCard, issue *github.Issue, action, column string) {
+	s.botChanges.add(card.GetID())
+	log.Printf("🤖 bot %s card %d of issue #%d %s in column %s (%d) of project %s (%d), delivery %s\n",
+		action, card.GetID(), issue.GetNumber(), issue.GetTitle(), column, b.columns[column].GetID(),
+		b.project.GetName(), b.project.GetID(), deliveryID(ctx))
+}
+
+// cleanUpIssueCards archives or deletes the cards of the issue, in the repository named "owner/name",
+// in the logical columns of the board, and returns how many there were.
+func (s *server) cleanUpIssueCards(ctx context.Context, b *board, columns []string, repo string, number int) (int, error) {
+	cleaned := 0
+	for _, column := range columns {
+		for _, lane := range b.withSetsOf(column) {
+			cards, err := s.listCards(ctx, lane, column)
+			if err != nil {
+				return cleaned, err
+			}
+			for _, card := range cards {
+				cardRepo, cardNumber, ok := parseContentURL(card.GetContentURL())
+				if !ok || cardNumber != number || !strings.EqualFold(cardRepo, repo) {
+					continue
+				}
+				if err := s.cleanUpCard(ctx, card); err != nil {
+					return cleaned, err
+				}
+				cleaned++
+			}
+		}
+	}
+	return cleaned, nil
+}
+
+// cleanUpCard archives or deletes the card according to the configuration.
+func (s *server) cleanUpCard(ctx context.Context, card *github.ProjectCard) error {
+	if s.cfg.issueTransferredAction == transferDelete {
+		if _, err := s.client.Projects.DeleteProjectCard(ctx, card.GetID()); err != nil {
+			return fmt.Errorf("delete project card %d: %w", card.GetID(), err)
+		}
+		return nil
+	}
+	archived := true
+	if _, _, err := s.client.Projects.UpdateProjectCard(ctx, card.GetID(), &github.ProjectCardOptions{Archived: &archived}); err != nil {
+		return fmt.Errorf("archive project card %d: %w", card.GetID(), err)
+	}
+	return nil
+}
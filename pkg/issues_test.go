@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func issuesEvent(action string, issue *github.Issue) *github.IssuesEvent {
+	return &github.IssuesEvent{
+		Action: github.String(action),
+		Issue:  issue,
+		Repo:   issue.Repository,
+		Sender: &github.User{Login: github.String("octocat")},
+	}
+}
+
+// The cards of transferred issues are archived or deleted per ISSUE_TRANSFERRED_ACTION,
+// and the cards of other issues and pull requests are left alone.
+func TestIssueTransferred(t *testing.T) {
+	for _, action := range []string{transferArchive, transferDelete} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"ISSUE_TRANSFERRED_ACTION": action})
+		transferred, other := f.issue(1), f.issue(2)
+		card := f.addIssueCard(f.column(BACKLOG), transferred)
+		f.addIssueCard(f.column(BACKLOG), other)
+		pr := f.pr(3)
+		f.addCard(f.column(IN_REVIEW), pr)
+
+		if rec := sendWebhook(t, s, "issues", issuesEvent("transferred", transferred)); rec.Code != http.StatusOK {
+			t.Errorf("%s: got status %d, want %d: %s", action, rec.Code, http.StatusOK, rec.Body.String())
+		}
+		got := f.issueCardOf(transferred)
+		switch {
+		case action == transferArchive && (got == nil || !got.Archived || got.ID != card.ID):
+			t.Errorf("%s: got card %v, want card %d archived", action, got, card.ID)
+		case action == transferDelete && got != nil:
+			t.Errorf("%s: got card %v, want it deleted", action, got)
+		}
+		if got := f.issueCardOf(other); got == nil || got.Archived {
+			t.Errorf("%s: got card %v for another issue, want it left alone", action, got)
+		}
+		if got := f.cardOf(pr); got == nil || got.Archived {
+			t.Errorf("%s: got card %v for a pull request, want it left alone", action, got)
+		}
+		if rec := sendWebhook(t, s, "issues", issuesEvent("transferred", transferred)); rec.Code != http.StatusAccepted {
+			t.Errorf("%s: got status %d for a transfer without cards, want %d", action, rec.Code, http.StatusAccepted)
+		}
+		f.close()
+	}
+}
+
+// Issues events are skipped without calling GitHub when ISSUE_TRANSFERRED_ACTION is unset.
+func TestIssueTransferredDisabled(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"EVENT_TYPES": "issues"})
+	issue := f.issue(1)
+	f.addIssueCard(f.column(BACKLOG), issue)
+	f.resetRequests()
+
+	if rec := sendWebhook(t, s, "issues", issuesEvent("transferred", issue)); rec.Code != http.StatusAccepted || len(f.requests()) != 0 {
+		t.Errorf("got status %d and requests %v, want %d and none", rec.Code, f.requests(), http.StatusAccepted)
+	}
+	if card := f.issueCardOf(issue); card == nil || card.Archived {
+		t.Errorf("got card %v, want it left alone", card)
+	}
+}
+
+// newIssueBoard adds the Planning project board of issues to the fake, with its To do and Done columns.
+func newIssueBoard(f *fakeGitHub) {
+	proj := f.addProject(OWNER+"/"+REPO, "Planning", "open")
+	f.addColumn(proj.ID, "To do")
+	f.addColumn(proj.ID, "Done")
+}
+
+var issueBoardEnv = map[string]string{
+	"ISSUE_COLUMNS":      "opened=To do, reopened=To do, closed=Done",
+	"ISSUE_PROJECT_NAME": "Planning",
+}
+
+// Issues and pull requests are placed in their own columns, on their own project boards.
+func TestIssueColumns(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	newIssueBoard(f)
+	s := newTestServer(t, f, issueBoardEnv)
+	issue, pr := f.issue(1), f.pr(2)
+
+	steps := []struct {
+		action string
+		status int
+		column string
+	}{
+		{"opened", http.StatusCreated, "To do"},
+		{"closed", http.StatusOK, "Done"},
+		{"reopened", http.StatusOK, "To do"},
+		{"reopened", http.StatusOK, "To do"},
+		{"edited", http.StatusAccepted, "To do"},
+	}
+	for _, step := range steps {
+		if rec := sendWebhook(t, s, "issues", issuesEvent(step.action, issue)); rec.Code != step.status {
+			t.Errorf("%s: got status %d, want %d: %s", step.action, rec.Code, step.status, rec.Body.String())
+		}
+		if card := f.issueCardOf(issue); card == nil || card.column != f.column(step.column) {
+			t.Errorf("%s: got card %v, want it in %s", step.action, card, step.column)
+		}
+	}
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusCreated {
+		t.Errorf("pull request: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	if card := f.cardOf(pr); card == nil || card.column != f.column(IN_REVIEW) {
+		t.Errorf("pull request: got card %v, want it in %s", card, IN_REVIEW)
+	}
+
+	// Closing an issue only moves its card.
+	other := f.issue(3)
+	if rec := sendWebhook(t, s, "issues", issuesEvent("closed", other)); rec.Code != http.StatusAccepted || f.issueCardOf(other) != nil {
+		t.Errorf("closed without card: got status %d and card %v, want %d and no card", rec.Code, f.issueCardOf(other), http.StatusAccepted)
+	}
+}
+
+// Without ISSUE_PROJECT_NAME, the cards of issues are placed on the board of pull requests.
+func TestIssueColumnsSameProject(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"ISSUE_COLUMNS": "opened=" + BACKLOG})
+	issue := f.issue(1)
+
+	if rec := sendWebhook(t, s, "issues", issuesEvent("opened", issue)); rec.Code != http.StatusCreated {
+		t.Errorf("got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	if card := f.issueCardOf(issue); card == nil || card.column != f.column(BACKLOG) {
+		t.Errorf("got card %v, want it in %s", card, BACKLOG)
+	}
+}
+
+// Transferred issues lose their cards on the board of issues as well.
+func TestIssueTransferredFromIssueBoard(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	newIssueBoard(f)
+	env := map[string]string{"ISSUE_TRANSFERRED_ACTION": transferDelete}
+	for k, v := range issueBoardEnv {
+		env[k] = v
+	}
+	s := newTestServer(t, f, env)
+	issue := f.issue(1)
+	f.addIssueCard(f.column("To do"), issue)
+
+	if rec := sendWebhook(t, s, "issues", issuesEvent("transferred", issue)); rec.Code != http.StatusOK || f.issueCardOf(issue) != nil {
+		t.Errorf("got status %d and card %v, want %d and no card", rec.Code, f.issueCardOf(issue), http.StatusOK)
+	}
+}
+
+func TestIssueColumnsConfig(t *testing.T) {
+	cfg := testConfig(t, issueBoardEnv)
+	if !contains(cfg.eventTypes, "issues") {
+		t.Errorf("got event types %v, want issues events processed", cfg.eventTypes)
+	}
+	if got := cfg.forIssues(); got.projectName != "Planning" || strings.Join(got.columns, ",") != "To do,Done" {
+		t.Errorf("got project %s and columns %v for issues, want Planning and [To do Done]", got.projectName, got.columns)
+	}
+	for name, env := range map[string]map[string]string{
+		"unknown action":         {"ISSUE_COLUMNS": "labeled=To do"},
+		"missing title":          {"ISSUE_COLUMNS": "opened="},
+		"project without column": {"ISSUE_PROJECT_NAME": "Planning"},
+	} {
+		if _, err := loadTestConfig(env); err == nil {
+			t.Errorf("%s: got no error, want one", name)
+		}
+	}
+}
+
+// The board of issues is checked at startup along with the board of pull requests.
+func TestIssueBoardChecked(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, issueBoardEnv)
+	if err := s.checkBoards(context.Background()); err == nil {
+		t.Error("without the board of issues: got no error, want one")
+	}
+	newIssueBoard(f)
+	if err := s.checkBoards(context.Background()); err != nil {
+		t.Errorf("with the board of issues: got error %v, want none", err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// plainLogPrefixes replaces the emoji starting log messages with plain text,
+// for log viewers that mangle UTF-8.
+var plainLogPrefixes = strings.NewReplacer(
+	"🚨 ", "[error] ",
+	"🤷‍♀️ ", "[skip] ",
+	"⚠️ ", "[warn] ",
+	"⏸️ ", "[paused] ",
+	"▶️ ", "[resumed] ",
+	"🏗️ ", "[created] ",
+	"🔁 ", "[poll] ",
+	"🚀 ", "[release] ",
+	"🚑 ", "[health] ",
+	"✅ ", "[ready] ",
+	"🔔 ", "[notify] ",
+	"📦 ", "[archive] ",
+	"🟢 ", "[check] ",
+	"🤖 ", "[bot] ",
+	"📋 ", "[activity] ",
+	"🔀 ", "[route] ",
+	"🧪 ", "[harness] ",
+)
+
+// plainLogWriter writes log messages with plain text prefixes instead of emoji.
+type plainLogWriter struct {
+	w io.Writer
+}
+
+func (p plainLogWriter) Write(b []byte) (int, error) {
+	if _, err := plainLogPrefixes.WriteString(p.w, string(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// throttledLog logs each message key at most once per interval, for warnings repeated on every event.
+type throttledLog struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newThrottledLog(interval time.Duration) *throttledLog {
+	return &throttledLog{interval: interval, last: make(map[string]time.Time)}
+}
+
+// printf logs the message unless a message with the same key was logged within the interval.
+func (t *throttledLog) printf(key, format string, v ...interface{}) {
+	t.mu.Lock()
+	now := time.Now()
+	if at, ok := t.last[key]; ok && now.Sub(at) < t.interval {
+		t.mu.Unlock()
+		return
+	}
+	t.last[key] = now
+	t.mu.Unlock()
+	log.Printf(format, v...)
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	_ "net/http/pprof"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-github/v29/github"
 	"github.com/julienschmidt/httprouter"
-	"golang.org/x/oauth2"
 )
 
 const (
@@ -20,6 +24,11 @@ const (
 	IN_PROGRESS     = "In progress"
 	IN_REVIEW       = "In review"
 	PENDING_RELEASE = "Pending release"
+	TRIAGE          = "Triage"
+	BLOCKED         = "Blocked"
+	FROZEN          = "Frozen"
+	RELEASED        = "Released"
+	EXTERNAL        = "External"
 )
 
 var (
@@ -27,142 +36,381 @@ var (
 	repoSecret = os.Getenv("GITHUB_TOKEN")
 )
 
+// allColumns are the logical columns every board must have.
 var allColumns = []string{BACKLOG, IN_PROGRESS, IN_REVIEW, PENDING_RELEASE}
 
-func getColumns(ctx context.Context, client *github.Client, proj *github.Project) (map[string]*github.ProjectColumn, error) {
-	projColumns := map[string]*github.ProjectColumn{
-		BACKLOG:         nil,
-		IN_PROGRESS:     nil,
-		IN_REVIEW:       nil,
-		PENDING_RELEASE: nil,
-	}
-	columns, _, err := client.Projects.ListProjectColumns(ctx, proj.GetID(), nil)
-	if err != nil {
-		return nil, err
-	}
-	for _, column := range columns {
-		name := column.GetName()
-		if _, ok := projColumns[name]; ok {
-			projColumns[name] = column
-		}
+// server handles the bot's HTTP endpoints.
+type server struct {
+	cfg    *config
+	client *github.Client
+	// paused is 1 while card mutations are paused, accessed atomically.
+	paused int32
+	// ready is the result of the startup board check.
+	ready readiness
+	// errors are the last reported errors.
+	errors *errorLog
+	// recent are the columns cards were just placed in.
+	recent *recentMoves
+	// touches are when the bot last changed each card.
+	touches *cardTouches
+	// missingProjects logs the repositories without the project board.
+	missingProjects *throttledLog
+	// queue holds the events acknowledged but not processed yet, nil when events are processed synchronously.
+	queue chan queuedEvent
+	// drafts are the columns cards were in before their pull request became a draft.
+	drafts *draftColumns
+	// repoConfigs are the configurations read from the repositories.
+	repoConfigs *repoConfigs
+	// slots bound how many events are processed at once.
+	slots *processingSlots
+	// opened are the placements of pull requests held back in their grace period.
+	opened *openedPlacements
+	// botChanges are the cards the bot just changed.
+	botChanges *botChanges
+	// sequencer processes the events of each pull request in the order they were received.
+	sequencer *prSequencer
+}
+
+func newServer(cfg *config) *server {
+	s := &server{
+		cfg:             cfg,
+		client:          newGitHubClient(cfg),
+		errors:          &errorLog{size: cfg.errorLogSize},
+		recent:          newRecentMoves(cfg.dedupWindow),
+		touches:         newCardTouches(cfg.manualCooldown),
+		missingProjects: newThrottledLog(cfg.missingProjectLogInterval),
+		drafts:          newDraftColumns(),
+		repoConfigs:     newRepoConfigs(cfg.repoConfigTTL),
+		slots:           newProcessingSlots(cfg.maxConcurrency, cfg.maxRepoConcurrency),
+		opened:          newOpenedPlacements(cfg.openedGracePeriod),
+		botChanges:      newBotChanges(),
+		sequencer:       newPRSequencer(),
 	}
-	for k, v := range projColumns {
-		if v == nil {
-			return nil, fmt.Errorf("column %s does not exist", k)
+	if cfg.asyncWorkers > 0 {
+		s.queue = make(chan queuedEvent, cfg.asyncQueueSize)
+		for i := 0; i < cfg.asyncWorkers; i++ {
+			go s.work()
 		}
 	}
-	return projColumns, nil
+	s.setPaused(cfg.paused)
+	s.ready.set(errors.New("project board not checked yet"))
+	return s
 }
 
-func handler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+func (s *server) handler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Ignored event types are acknowledged before doing any work.
+	if eventType := github.WebHookType(req); !contains(s.cfg.eventTypes, eventType) {
+		log.Printf("🤷‍♀️ event type %s is not processed\n", eventType)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Until the board was resolved, events are refused rather than processed against an unresolved board.
+	if err := s.ready.get(); err != nil && s.cfg.waitForReady {
+		log.Printf("⚠️ refusing event %s until the project board is ready: err=%s\n", github.WebHookType(req), err)
+		w.Header().Set("Retry-After", strconv.Itoa(int(readyRetryInterval.Seconds())))
+		http.Error(w, "project board is not ready", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Validate payload.
-	payload, err := github.ValidatePayload(req, []byte(os.Getenv("WEBHOOK_SECRET")))
+	if s.cfg.webhookSecret == "" {
+		log.Printf("⚠️ accepting unsigned webhook %s, set WEBHOOK_SECRET to verify signatures\n", github.WebHookType(req))
+	}
+	payload, err := s.validatePayload(req)
+	if errors.Is(err, errMissingSignature) {
+		// Unsigned requests are more likely a probe hitting the wrong path than tampering.
+		missingSignatures.inc()
+		log.Printf("⚠️ refusing webhook without a signature header from %s\n", sourceIP(req, s.cfg.trustedProxies))
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 	if err != nil {
-		log.Printf("🚨 error validating request body: err=%s\n", err)
+		// The source helps telling a secret rotation mistake from someone probing the endpoint.
+		signatureFailures.inc()
+		log.Printf("🚨 error validating request body from %s: err=%s\n", sourceIP(req, s.cfg.trustedProxies), err)
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 	defer req.Body.Close()
+	webhookEvents.inc(eventTypeLabel(github.WebHookType(req)))
+
+	if s.isPaused() {
+		log.Printf("⏸️ bot is paused, ignoring event %s\n", github.WebHookType(req))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 
 	// Parse payload to get the event.
-	event, err := github.ParseWebHook(github.WebHookType(req), payload)
+	eventType, payload, err := s.cfg.adapter.adapt(github.WebHookType(req), payload)
+	if err != nil {
+		log.Printf("🚨 error could not adapt webhook: err=%s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := github.ParseWebHook(eventType, payload)
 	if err != nil {
 		log.Printf("🚨 error could not parse webhook: err=%s\n", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Auth to perform create/move card actions.
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: repoSecret},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	var client = github.NewClient(tc)
+	// Events of repositories out of scope, such as from an organization webhook, are acknowledged.
+	var repo string
+	if e, ok := event.(interface{ GetRepo() *github.Repository }); ok {
+		repo = e.GetRepo().GetFullName()
+		if !s.cfg.isManagedRepo(repo) {
+			log.Printf("🤷‍♀️ repository %s is not managed, ignoring event %s\n", repo, github.WebHookType(req))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	ev := queuedEvent{
+		deliveryID: github.DeliveryID(req),
+		eventType:  github.WebHookType(req),
+		repo:       repo,
+		event:      event,
+		payload:    payload,
+	}
+	if !s.admit(w, ev) {
+		return
+	}
+	ev.turn = s.sequencer.reserve(sequenceKey(event))
+	if s.queue != nil {
+		s.enqueue(w, ev)
+		return
+	}
+	defer s.slots.release(ev.repo)
+	s.sequencer.run(ev.turn, func() { s.process(w, ev) })
+	<-ev.turn.done
+}
+
+// handlePullRequest moves the card of the pull request according to the event's action.
+// The payload of the event is needed for the changes go-github doesn't parse.
+func (s *server) handlePullRequest(ctx context.Context, w http.ResponseWriter, e *github.PullRequestEvent, payload []byte) {
+	pr := e.GetPullRequest()
+	if isMissingPullRequest(pr) {
+		log.Printf("🚨 error pull_request event without a pull request: payload=%s\n", truncatePayload(payload))
+		http.Error(w, "event has no pull request", http.StatusBadRequest)
+		return
+	}
+	if !s.cfg.isTrackedBranch(pr.GetBase().GetRef()) {
+		log.Printf("🤷‍♀️ pr %s targets untracked branch %s, skipping\n", pr.GetTitle(), pr.GetBase().GetRef())
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
 
-	switch e := event.(type) {
-	case *github.PullRequestEvent:
-		if e.GetAction() != "opened" {
-			w.WriteHeader(http.StatusAccepted)
+	// Note cards can't hold labels, their body mirrors the labels of the pull request instead.
+	if action := e.GetAction(); s.cfg.syncNoteLabels && (action == "labeled" || action == "unlabeled") {
+		if err := s.syncNotes(ctx, pr); err != nil {
+			s.fail(ctx, w, fmt.Sprintf("syncing note cards of pr %s", pr.GetTitle()), err)
 			return
 		}
+	}
 
-		pr := e.GetPullRequest()
+	if s.routed(ctx, w, e.GetAction(), pr, payload) {
+		return
+	}
 
-		// Get the project we want.
-		projects, _, err := client.Repositories.ListProjects(ctx, OWNER, REPO, nil)
-		if err != nil {
-			log.Printf("🚨 error getting project name: err=%s\n", err)
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+	// Some actions only move existing cards back and never create them.
+	var p placement
+	switch action := e.GetAction(); {
+	case action == "opened":
+		p = placement{column: s.cfg.openedColumn(pr), create: true}
+	case action == "edited" && len(s.cfg.baseBranches) > 0:
+		// Retargeting a pull request from an untracked branch brings it on the board.
+		from, ok := changedBase(payload)
+		if !ok || s.cfg.isTrackedBranch(from) {
+			w.WriteHeader(s.cfg.statusCode(skipped))
 			return
 		}
-		if projName := projects[0].GetName(); projName != PROJECT_NAME {
-			log.Printf("🚨 error project %s not found: err=%s\n", projName, err)
-			http.Error(w, fmt.Sprintf("project %s not found", projName), http.StatusUnauthorized)
+		p = placement{column: s.cfg.openedColumn(pr), create: true, keep: true}
+	case action == "review_requested" && s.cfg.moveOnReviewRequested:
+		p = placement{column: IN_REVIEW, create: true}
+	case action == "review_request_removed" && s.cfg.moveOnReviewRequestRemoved:
+		// Only move the card back once no reviewers are left.
+		if len(pr.RequestedReviewers) > 0 || len(pr.RequestedTeams) > 0 {
+			w.WriteHeader(s.cfg.statusCode(skipped))
 			return
 		}
-		proj := projects[0]
-
-		// Get the column info
-		columns, err := getColumns(ctx, client, proj)
+		p = placement{column: IN_PROGRESS}
+	case action == "synchronize" && s.cfg.onSynchronize == syncAdvance:
+		// Pushes are frequent, only non-draft pull requests are worth looking up the board for.
+		if pr.GetDraft() {
+			w.WriteHeader(s.cfg.statusCode(skipped))
+			return
+		}
+		p = placement{column: IN_REVIEW, from: []string{IN_PROGRESS}}
+	case action == "synchronize" && s.cfg.onSynchronize == syncMergeable:
+		state, err := mergeableState(ctx, s.client, s.cfg, e.GetRepo(), pr)
 		if err != nil {
-			log.Printf("🚨 error getting project columns: err=%s\n", err)
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			s.fail(ctx, w, fmt.Sprintf("getting mergeable state of pr %s", pr.GetTitle()), err)
 			return
 		}
+		if state != "dirty" {
+			w.WriteHeader(s.cfg.statusCode(skipped))
+			return
+		}
+		p = placement{column: IN_PROGRESS}
+	case action == "labeled" && s.cfg.blockedLabel != "":
+		if !strings.EqualFold(e.GetLabel().GetName(), s.cfg.blockedLabel) {
+			w.WriteHeader(s.cfg.statusCode(skipped))
+			return
+		}
+		p = placement{column: BLOCKED, create: true}
+	case action == "unlabeled" && s.cfg.blockedLabel != "":
+		if !strings.EqualFold(e.GetLabel().GetName(), s.cfg.blockedLabel) {
+			w.WriteHeader(s.cfg.statusCode(skipped))
+			return
+		}
+		p = placement{column: s.cfg.unblockedColumn(pr), from: []string{BLOCKED}}
+	case action == "converted_to_draft" && s.cfg.moveOnDraft:
+		p = placement{column: IN_PROGRESS, from: []string{IN_REVIEW, PENDING_RELEASE}, saveDraft: true}
+	case action == "ready_for_review" && s.cfg.moveOnDraft:
+		p = placement{column: IN_REVIEW, from: []string{IN_PROGRESS}, restoreDraft: true}
+	case action == "locked" && contains(s.cfg.columns, FROZEN):
+		p = placement{column: FROZEN}
+	case action == "unlocked" && contains(s.cfg.columns, FROZEN):
+		// Unlocked pull requests go back where their state puts them.
+		column := s.cfg.unblockedColumn(pr)
+		if s.cfg.isBlocked(pr) {
+			column = BLOCKED
+		}
+		p = placement{column: column, from: []string{FROZEN}}
+	case action == "milestoned" && s.cfg.activeMilestone != "":
+		if !s.cfg.isActiveMilestone(pr.GetMilestone()) {
+			w.WriteHeader(s.cfg.statusCode(skipped))
+			return
+		}
+		p = placement{column: IN_PROGRESS, create: true}
+	case action == "demilestoned" && s.cfg.activeMilestone != "":
+		// Cards the milestone brought in progress go back to the backlog.
+		p = placement{column: BACKLOG, from: []string{IN_PROGRESS}}
+	case action == "closed" && s.cfg.archiveAfter > 0:
+		// Closed pull requests wait in the terminal column to be archived,
+		// except merged ones waiting for their release.
+		column := s.cfg.archiveColumn
+		if pr.GetMerged() && s.cfg.releaseEnvironment != "" {
+			column = s.cfg.mergedColumn
+		}
+		p = placement{column: column}
+	default:
+		// Unhandled actions are acknowledged without talking to GitHub.
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
 
-		// Get all cards in the project.
-		var cards []*github.ProjectCard
-		for _, columnName := range allColumns {
-			columnCards, resp, err := client.Projects.ListProjectCards(ctx, columns[columnName].GetID(), nil)
+	if s.debounce(ctx, e.GetAction(), pr, p) {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	s.applyPlacement(ctx, w, pr, p)
+}
+
+// handlePullRequestReview moves the card of the pull request according to the review:
+// submitted reviews advance the card to the column configured for their state, and dismissed
+// approvals, such as after a force-push, move it back to IN_REVIEW.
+func (s *server) handlePullRequestReview(ctx context.Context, w http.ResponseWriter, e *github.PullRequestReviewEvent, payload []byte) {
+	pr := e.GetPullRequest()
+	if isMissingPullRequest(pr) {
+		log.Printf("🚨 error pull_request_review event without a pull request: payload=%s\n", truncatePayload(payload))
+		http.Error(w, "event has no pull request", http.StatusBadRequest)
+		return
+	}
+	if !s.cfg.isTrackedBranch(pr.GetBase().GetRef()) {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	var p placement
+	switch action, state := e.GetAction(), strings.ToLower(e.GetReview().GetState()); {
+	case action == "submitted" && s.cfg.reviewColumns[state] != "":
+		column := s.cfg.reviewColumns[state]
+		if column == PENDING_RELEASE && s.cfg.minApprovals > 1 {
+			approvals, err := countApprovals(ctx, s.client, e.GetRepo(), pr)
 			if err != nil {
-				log.Printf("🚨 error listing project cards for column %s: err=%s\n", IN_REVIEW, err)
-				http.Error(w, err.Error(), resp.StatusCode)
+				s.fail(ctx, w, fmt.Sprintf("counting approvals of pr %s", pr.GetTitle()), err)
 				return
 			}
-			cards = append(cards, columnCards...)
-		}
-
-		// Checkout if the card related to the PR already exists or not.
-		cardID := int64(0)
-		for _, card := range cards {
-			if card.GetNodeID() == pr.GetNodeID() {
-				cardID = card.GetID()
-				break
+			if approvals < s.cfg.minApprovals {
+				log.Printf("🤷‍♀️ pr %s has %d of %d approvals, leaving it in review\n", pr.GetTitle(), approvals, s.cfg.minApprovals)
+				w.WriteHeader(s.cfg.statusCode(skipped))
+				return
 			}
 		}
-
-		// If the card exists, move the card to "In review" column.
-		if cardID == 0 {
-			_, resp, err := client.Projects.CreateProjectCard(ctx, columns[IN_REVIEW].GetID(), &github.ProjectCardOptions{
-				ContentID:   pr.GetID(),
-				ContentType: "PullRequest",
-			})
+		if column == PENDING_RELEASE && s.cfg.requireReviewDecision {
+			decision, err := reviewDecision(ctx, s.client, e.GetRepo(), pr)
 			if err != nil {
-				log.Printf("🚨 error creating project cards for pr %s: err=%s\n", pr.GetTitle(), err)
-				http.Error(w, err.Error(), resp.StatusCode)
+				s.fail(ctx, w, fmt.Sprintf("getting review decision of pr %s", pr.GetTitle()), err)
+				return
+			}
+			if decision != "" && decision != "APPROVED" {
+				log.Printf("🤷‍♀️ pr %s is missing required approvals, review decision is %s, leaving it in review\n", pr.GetTitle(), decision)
+				w.WriteHeader(s.cfg.statusCode(skipped))
 				return
 			}
-			w.WriteHeader(http.StatusCreated)
-			return
 		}
+		p = placement{column: column, from: s.cfg.columnsBefore(column)}
+	case action == "dismissed" && s.cfg.moveOnReviewDismissed:
+		p = placement{column: IN_REVIEW, from: []string{PENDING_RELEASE}}
+	default:
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	s.applyPlacement(ctx, w, pr, p)
+}
 
-		// If not, create a new card related to the PR in "In review" column.
-		resp, err := client.Projects.MoveProjectCard(ctx, cardID, &github.ProjectCardMoveOptions{
-			Position: "bottom",
-			ColumnID: columns[IN_REVIEW].GetID(),
-		})
-		if err != nil {
-			log.Printf("🚨 error moving project cards for pr %s: err=%s\n", pr.GetTitle(), err)
-			http.Error(w, err.Error(), resp.StatusCode)
+// applyPlacement places the card of the pull request on the boards, and replies with the outcome.
+func (s *server) applyPlacement(ctx context.Context, w http.ResponseWriter, pr *github.PullRequest, p placement) {
+	// Get the project and columns we want.
+	boards, err := s.repoBoards(ctx, pr.GetBase().GetRepo())
+	if err != nil {
+		if repo, ok := s.cfg.skipsBoardError(err); ok {
+			s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping events of %s\n", err, repo)
+			w.WriteHeader(http.StatusOK)
 			return
 		}
-		w.WriteHeader(http.StatusCreated)
+		s.fail(ctx, w, "getting project board", err)
 		return
-	default:
-		log.Printf("🤷‍♀️ event type %s\n", github.WebHookType(req))
+	}
+
+	status, err := s.placeCards(ctx, boards, pr, p)
+	if err != nil {
+		stage := fmt.Sprintf("placing card for pr %s in column %s", pr.GetTitle(), p.column)
+		if status != http.StatusMultiStatus {
+			s.fail(ctx, w, stage, err)
+			return
+		}
+		// Some boards were updated, the reply lists the ones that failed.
+		err = s.report(ctx, stage, err)
+		http.Error(w, err.Error(), status)
 		return
 	}
+	w.WriteHeader(status)
+}
+
+// sourceIP returns the IP address the request comes from, as reported by the trusted proxies in front of the bot.
+// Each proxy appends the address it received the request from to X-Forwarded-For, so the client is the hop
+// the outermost trusted proxy added; the hops before it may be forged by the client.
+func sourceIP(req *http.Request, trustedProxies int) string {
+	var hops []string
+	for _, header := range req.Header["X-Forwarded-For"] {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	if trustedProxies > 0 && len(hops) > 0 {
+		if trustedProxies > len(hops) {
+			return hops[0]
+		}
+		return hops[len(hops)-trustedProxies]
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
 }
 
 func healthCheckHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -170,16 +418,47 @@ func healthCheckHandler(w http.ResponseWriter, req *http.Request, ps httprouter.
 	w.WriteHeader(http.StatusOK)
 }
 
-func main() {
+// subcommands are alternative entrypoints selected by the first command line argument.
+var subcommands = map[string]func(cfg *config) error{}
 
+func newRouter(s *server) *httprouter.Router {
 	router := httprouter.New()
 
 	// Webhooks endpoint
-	router.POST("/api/projectbot", handler)
+	if !s.cfg.disableWebhooks {
+		router.POST("/api/projectbot", s.rateLimited(s.handler))
+	}
 
 	// Health Check
 	router.GET("/", healthCheckHandler)
 
+	// Readiness, once the project board was resolved.
+	router.GET("/ready", s.readyHandler)
+
+	// Metrics in the Prometheus text format.
+	router.GET("/metrics", metricsHandler)
+
+	// Admin endpoints, only available when an admin token is configured.
+	if s.cfg.adminToken != "" {
+		router.POST("/admin/pause", s.requireAdmin(s.pauseHandler))
+		router.POST("/admin/resume", s.requireAdmin(s.resumeHandler))
+		router.POST("/admin/move", s.requireAdmin(s.moveHandler))
+		router.GET("/admin/errors", s.requireAdmin(s.errorsHandler))
+		router.GET("/admin/export", s.requireAdmin(s.exportHandler))
+		router.GET("/admin/columns", s.requireAdmin(s.columnsHandler))
+		router.GET("/stats", s.requireAdmin(s.statsHandler))
+		if s.cfg.selfTestColumn != "" {
+			router.POST("/admin/selftest", s.requireAdmin(s.selfTestHandler))
+		}
+	}
+
+	// Profiling, registered on the default mux by net/http/pprof.
+	// It must stay opt-in as it shouldn't be exposed publicly.
+	if s.cfg.enablePprof {
+		router.Handler(http.MethodGet, "/debug/pprof/*item", http.DefaultServeMux)
+		router.Handler(http.MethodPost, "/debug/pprof/*item", http.DefaultServeMux)
+	}
+
 	router.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		header := w.Header()
@@ -190,6 +469,47 @@ func main() {
 		// Adjust status code to 204
 		w.WriteHeader(http.StatusNoContent)
 	})
+	return router
+}
 
-	log.Fatal(http.ListenAndServe(":80", router))
+func main() {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("🚨 error loading config: err=%s\n", err)
+	}
+	if cfg.plainLogs {
+		log.SetOutput(plainLogWriter{w: os.Stderr})
+	}
+
+	if len(os.Args) > 1 {
+		run, ok := subcommands[os.Args[1]]
+		if !ok {
+			log.Fatalf("🚨 error unknown command %s\n", os.Args[1])
+		}
+		if err := run(cfg); err != nil {
+			log.Fatalf("🚨 error running %s: err=%s\n", os.Args[1], err)
+		}
+		return
+	}
+
+	s := newServer(cfg)
+	if err := s.checkBoard(context.Background()); err != nil {
+		if cfg.exitOnInvalidToken && errors.Is(err, errInvalidToken) {
+			log.Fatalf("🚨 error starting: err=%s\n", err)
+		}
+		go s.retryBoardCheck()
+	}
+	if cfg.pollInterval > 0 {
+		go s.poll(cfg.pollInterval)
+	}
+	if cfg.cardMetricsInterval > 0 {
+		go s.observeCards(cfg.cardMetricsInterval)
+	}
+	if cfg.staleInterval > 0 {
+		go s.sweepStale(cfg.staleInterval)
+	}
+	if cfg.archiveAfter > 0 {
+		go s.archiveCards(archiveInterval(cfg.archiveAfter))
+	}
+	log.Fatal(http.ListenAndServe(":80", newHandler(s)))
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Events of different pull requests handled in parallel each place their own card, which with -race checks
+// that handlers don't share mutable state.
+func TestHandlerConcurrentEvents(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		pr := f.pr(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusCreated {
+				t.Errorf("pr #%d: got status %d, want %d", pr.GetNumber(), rec.Code, http.StatusCreated)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cards := f.cardsIn(f.column(IN_REVIEW))
+	if len(cards) != n {
+		t.Fatalf("got %d cards in %s, want %d", len(cards), IN_REVIEW, n)
+	}
+	seen := make(map[int64]bool)
+	for _, card := range cards {
+		if seen[card.contentID] {
+			t.Errorf("got several cards for pull request %d", card.contentID)
+		}
+		seen[card.contentID] = true
+	}
+}
+
+// edited returns an edited pull_request event retargeting the pull request from the base branch.
+func edited(t *testing.T, pr *github.PullRequest, from string) string {
+	t.Helper()
+	var event map[string]interface{}
+	b, _ := json.Marshal(prEvent("edited", pr))
+	json.Unmarshal(b, &event)
+	event["changes"] = map[string]interface{}{"base": map[string]interface{}{"ref": map[string]string{"from": from}}}
+	b, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("encode edited event: %v", err)
+	}
+	return string(b)
+}
+
+// Retargeting a pull request re-evaluates it against the tracked base branches.
+func TestBaseBranchChange(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"BASE_BRANCHES": "master"})
+
+	// Retargeted out of scope, the card is left alone.
+	out := f.pr(1)
+	out.Base.Ref = github.String("release")
+	f.addPR(out)
+	f.addCard(f.column(IN_PROGRESS), out)
+	if rec := sendWebhook(t, s, "pull_request", edited(t, out, "master")); rec.Code != http.StatusAccepted {
+		t.Errorf("retargeted to release: got status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got := f.cardsIn(f.column(IN_PROGRESS)); len(got) != 1 {
+		t.Errorf("retargeted to release: got cards %v in %s, want the card left", got, IN_PROGRESS)
+	}
+
+	// Retargeted into scope, the card is added.
+	in := f.pr(2)
+	if rec := sendWebhook(t, s, "pull_request", edited(t, in, "release")); rec.Code != http.StatusCreated || f.cardOf(in) == nil {
+		t.Errorf("retargeted to master: got status %d, want the card created", rec.Code)
+	}
+
+	// Edits keeping the base branch don't touch the board.
+	f.resetRequests()
+	if rec := sendWebhook(t, s, "pull_request", prEvent("edited", f.pr(3))); rec.Code != http.StatusAccepted || len(f.mutations()) != 0 {
+		t.Errorf("edited title: got status %d and requests %v, want nothing done", rec.Code, f.mutations())
+	}
+}
+
+// Events of repositories outside the allowlist or in the denylist are acknowledged without touching a board.
+func TestManagedRepositories(t *testing.T) {
+	testCases := map[string]struct {
+		env     map[string]string
+		managed map[string]bool
+	}{
+		"allowlist": {
+			env:     map[string]string{"REPO_ALLOWLIST": "acme/widgets, ACME/Gadgets"},
+			managed: map[string]bool{"acme/widgets": true, "acme/gadgets": true, "acme/tools": false},
+		},
+		"denylist": {
+			env:     map[string]string{"REPO_DENYLIST": "acme/tools"},
+			managed: map[string]bool{"acme/widgets": true, "acme/gadgets": true, "acme/tools": false},
+		},
+		"both": {
+			env:     map[string]string{"REPO_ALLOWLIST": "acme/widgets,acme/tools", "REPO_DENYLIST": "acme/tools"},
+			managed: map[string]bool{"acme/widgets": true, "acme/gadgets": false, "acme/tools": false},
+		},
+	}
+	for name, tc := range testCases {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, tc.env)
+		for repo, managed := range tc.managed {
+			parts := strings.Split(repo, "/")
+			f.addProject(repo, PROJECT_NAME, "open")
+			f.resetRequests()
+			rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.newRepoPR(parts[0], parts[1], 1)))
+			if !managed && (rec.Code != http.StatusOK || len(f.requests()) != 0) {
+				t.Errorf("%s: %s: got status %d and requests %v, want the event ignored", name, repo, rec.Code, f.requests())
+			}
+			if managed && len(f.requests()) == 0 {
+				t.Errorf("%s: %s: got no requests, want the event processed", name, repo)
+			}
+		}
+		f.close()
+	}
+}
+
+// Locked pull requests move to the frozen column and back on unlock, only when it's configured.
+func TestLockedPullRequests(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	pr := f.pr(1)
+	f.addCard(f.column(IN_PROGRESS), pr)
+	f.resetRequests()
+	for _, action := range []string{"locked", "unlocked"} {
+		if rec := sendWebhook(t, s, "pull_request", prEvent(action, pr)); rec.Code != http.StatusAccepted || len(f.requests()) != 0 {
+			t.Errorf("%s without FROZEN_COLUMN: got status %d and requests %v, want nothing done", action, rec.Code, f.requests())
+		}
+	}
+
+	s = newTestServer(t, f, map[string]string{"FROZEN_COLUMN": "Frozen"})
+	frozen := f.addColumn(f.projects[0].ID, "Frozen")
+	sendWebhook(t, s, "pull_request", prEvent("locked", pr))
+	if got := f.cardOf(pr).column; got != frozen.ID {
+		t.Errorf("locked: got card in column %d, want Frozen (%d)", got, frozen.ID)
+	}
+	sendWebhook(t, s, "pull_request", prEvent("unlocked", pr))
+	if got := f.cardOf(pr).column; got != f.column(IN_REVIEW) {
+		t.Errorf("unlocked: got card in column %d, want %s (%d)", got, IN_REVIEW, f.column(IN_REVIEW))
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// metrics are exposed in the Prometheus text format on /metrics.
+var metrics = &registry{}
+
+var (
+	conditionalRequests = metrics.counter("projectbot_github_conditional_requests_total",
+		"GitHub GET requests sent with the ETag of a cached response.")
+	notModifiedResponses = metrics.counter("projectbot_github_not_modified_total",
+		"GitHub responses served from the ETag cache, which don't count against the rate limit.")
+	signatureFailures = metrics.counter("projectbot_webhook_signature_failures_total",
+		"Webhooks rejected because their signature didn't match the webhook secret.")
+	missingSignatures = metrics.counter("projectbot_webhook_missing_signatures_total",
+		"Webhooks rejected because they had no signature header while a webhook secret is set.")
+	webhookEvents = metrics.counter("projectbot_webhook_events_total",
+		"Webhooks received, by event type.", "type")
+	cardsCreated = metrics.counter("projectbot_cards_created_total",
+		"Cards added to the project boards.")
+	cardsMoved = metrics.counter("projectbot_cards_moved_total",
+		"Cards moved between columns.")
+	reportedErrors = metrics.counter("projectbot_errors_total",
+		"Errors reported while processing webhooks and background tasks.")
+	queuedEvents = metrics.gauge("projectbot_queued_events",
+		"Acknowledged webhook events waiting to be processed.")
+	droppedEvents = metrics.counter("projectbot_dropped_events_total",
+		"Webhook events refused because the event queue was full.")
+	rateLimitedRequests = metrics.counter("projectbot_webhook_rate_limited_total",
+		"Webhooks refused because they exceeded the rate limits.")
+	throttledEvents = metrics.counter("projectbot_throttled_events_total",
+		"Webhook events refused because their repository or the bot had no processing slot left.")
+	boardChanges = metrics.counter("projectbot_board_changes_total",
+		"Cards created or moved on the boards according to project_card webhooks, by the bot or by people.", "action", "actor")
+	columnCards = metrics.gauge("projectbot_column_cards",
+		"Cards in each column of the project boards.", "project", "column")
+)
+
+// observeCards refreshes the card counts of the boards every interval.
+func (s *server) observeCards(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		boards, err := s.repoBoards(ctx, homeRepository())
+		if err == nil {
+			err = s.countCards(ctx, boards)
+		}
+		if err != nil {
+			s.report(ctx, "counting cards", err)
+		}
+		<-ticker.C
+	}
+}
+
+// countCards sets the card counts of the managed columns of the boards.
+func (s *server) countCards(ctx context.Context, boards []*board) error {
+	for _, b := range boards {
+		for _, name := range s.cfg.columns {
+			for _, lane := range b.withSetsOf(name) {
+				cards, err := s.listCards(ctx, lane, name)
+				if err != nil {
+					return err
+				}
+				columnCards.set(float64(len(cards)), lane.project.GetName(), lane.columns[name].GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// countedEventTypes are the event types webhookEvents counts by name.
+var countedEventTypes = []string{
+	"pull_request", "pull_request_review", "deployment_status", "issue_comment", "milestone",
+	"project_card", "issues", "check_run", "repository_dispatch",
+}
+
+// eventTypeLabel returns the label webhookEvents counts the event type under,
+// "other" for the types the bot doesn't handle so that the metric keeps a bounded set of labels.
+func eventTypeLabel(eventType string) string {
+	if contains(countedEventTypes, eventType) {
+		return eventType
+	}
+	return "other"
+}
+
+// registry holds the metrics in the order they were declared.
+type registry struct {
+	mu   sync.Mutex
+	vecs []*metricVec
+}
+
+// metricVec is a counter or gauge, with one sample per combination of label values.
+type metricVec struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu      sync.Mutex
+	samples map[string]*sample
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+func (r *registry) counter(name, help string, labels ...string) *metricVec {
+	return r.register(name, help, "counter", labels)
+}
+
+func (r *registry) gauge(name, help string, labels ...string) *metricVec {
+	return r.register(name, help, "gauge", labels)
+}
+
+func (r *registry) register(name, help, kind string, labels []string) *metricVec {
+	v := &metricVec{name: name, help: help, kind: kind, labels: labels, samples: make(map[string]*sample)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.vecs = append(r.vecs, v)
+	return v
+}
+
+// inc adds one to the sample with the label values.
+func (v *metricVec) inc(labelValues ...string) {
+	v.add(1, labelValues...)
+}
+
+// add adds delta to the sample with the label values.
+func (v *metricVec) add(delta float64, labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sample(labelValues).value += delta
+}
+
+// set sets the sample with the label values, for gauges.
+func (v *metricVec) set(value float64, labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sample(labelValues).value = value
+}
+
+func (v *metricVec) sample(labelValues []string) *sample {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.samples[key]
+	if !ok {
+		s = &sample{labelValues: labelValues}
+		v.samples[key] = s
+	}
+	return s
+}
+
+// total returns the sum of the samples.
+func (v *metricVec) total() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var total float64
+	for _, s := range v.samples {
+		total += s.value
+	}
+	return total
+}
+
+// byFirstLabel returns the samples keyed by the value of their first label.
+func (v *metricVec) byFirstLabel() map[string]float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	values := make(map[string]float64)
+	for _, s := range v.samples {
+		if len(s.labelValues) > 0 {
+			values[s.labelValues[0]] += s.value
+		}
+	}
+	return values
+}
+
+// write writes the metrics in the Prometheus text exposition format.
+func (r *registry) write(w io.Writer) {
+	r.mu.Lock()
+	vecs := append([]*metricVec(nil), r.vecs...)
+	r.mu.Unlock()
+	for _, v := range vecs {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, v.kind)
+		v.mu.Lock()
+		if len(v.labels) == 0 && len(v.samples) == 0 {
+			fmt.Fprintf(w, "%s 0\n", v.name)
+		}
+		keys := make([]string, 0, len(v.samples))
+		for key := range v.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := v.samples[key]
+			fmt.Fprintf(w, "%s%s %g\n", v.name, formatLabels(v.labels, s.labelValues), s.value)
+		}
+		v.mu.Unlock()
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=%q", name, value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func metricsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metrics.write(w)
+}
+
+// startTime is when the bot started, for the uptime in /stats.
+var startTime = time.Now()
+
+// stats is a summary of the lifetime counters.
+type stats struct {
+	UptimeSeconds float64            `json:"uptime_seconds"`
+	Events        map[string]float64 `json:"events"`
+	CardsCreated  float64            `json:"cards_created"`
+	CardsMoved    float64            `json:"cards_moved"`
+	Errors        float64            `json:"errors"`
+}
+
+// statsHandler replies with a summary of the counters behind /metrics.
+func (s *server) statsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	writeJSON(w, http.StatusOK, stats{
+		UptimeSeconds: time.Since(startTime).Seconds(),
+		Events:        webhookEvents.byFirstLabel(),
+		CardsCreated:  cardsCreated.total(),
+		CardsMoved:    cardsMoved.total(),
+		Errors:        reportedErrors.total(),
+	})
+}
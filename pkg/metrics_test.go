@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Webhooks are only counted once their signature is valid, by event type or as "other".
+func TestWebhookEventsCounted(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"EVENT_TYPES": "pull_request,push"})
+	before := webhookEvents.byFirstLabel()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/projectbot", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-Hub-Signature", "sha1=0000000000000000000000000000000000000000")
+	rec := httptest.NewRecorder()
+	newHandler(s).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("forged webhook: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	sendWebhook(t, s, "pull_request", prEvent("closed", f.pr(1)))
+	sendWebhook(t, s, "push", `{"ref": "refs/heads/master"}`)
+
+	after := webhookEvents.byFirstLabel()
+	if got := after["pull_request"] - before["pull_request"]; got != 1 {
+		t.Errorf("got %v pull_request webhooks counted, want 1", got)
+	}
+	if got := after["other"] - before["other"]; got != 1 {
+		t.Errorf("got %v other webhooks counted, want 1", got)
+	}
+	if _, ok := after["push"]; ok {
+		t.Error("got push webhooks counted by name, want them counted as other")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// middleware wraps a handler with behavior shared by all the endpoints.
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h with the middlewares, the first one being the outermost: it sees requests first and responses last.
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// middlewares are the middlewares wrapping the router, in order.
+func (s *server) middlewares() []middleware {
+	return []middleware{
+		recoverPanics,
+	}
+}
+
+// newHandler returns the router of the endpoints wrapped with the middlewares.
+func newHandler(s *server) http.Handler {
+	return chain(newRouter(s), s.middlewares()...)
+}
+
+// recoverPanics answers requests whose handler panicked with 500 Internal Server Error instead of dropping the connection.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				log.Printf("🚨 panic serving %s %s: %v\n%s", req.Method, req.URL.Path, v, debug.Stack())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
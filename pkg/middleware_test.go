@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Chained middlewares run outer to inner on the way in, and inner to outer on the way out.
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				calls = append(calls, name+" in")
+				next.ServeHTTP(w, req)
+				calls = append(calls, name+" out")
+			})
+		}
+	}
+	h := chain(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls = append(calls, "handler")
+	}), record("first"), record("second"), record("third"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	want := "first in, second in, third in, handler, third out, second out, first out"
+	if got := strings.Join(calls, ", "); got != want {
+		t.Errorf("got calls %s, want %s", got, want)
+	}
+}
+
+// Handlers that panic are answered with 500 Internal Server Error.
+func TestRecoverPanics(t *testing.T) {
+	h := recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// archiveTarget is the MILESTONE_CLOSED_TARGET archiving the cards instead of moving them.
+const archiveTarget = "archive"
+
+// handleMilestone sweeps the cards of the pull requests left open in a milestone when it's closed,
+// such as at the end of a sprint, to the configured column or the archive.
+func (s *server) handleMilestone(ctx context.Context, w http.ResponseWriter, e *github.MilestoneEvent) {
+	if e.GetAction() != "closed" || s.cfg.milestoneClosedTarget == "" {
+		w.WriteHeader(s.cfg.statusCode(skipped))
+		return
+	}
+	repo := e.GetRepo()
+	prs, err := listMilestonePullRequests(ctx, s.client, repo, e.GetMilestone())
+	if err != nil {
+		s.fail(ctx, w, fmt.Sprintf("listing pull requests of milestone %s", e.GetMilestone().GetTitle()), err)
+		return
+	}
+	boards, err := s.repoBoards(ctx, repo)
+	if err != nil {
+		s.fail(ctx, w, "getting project board", err)
+		return
+	}
+	swept := 0
+	for _, pr := range prs {
+		for _, b := range boards {
+			ok, err := s.sweepMilestoneCard(ctx, b, pr)
+			if err != nil {
+				s.fail(ctx, w, fmt.Sprintf("sweeping card for pr %s", pr.GetTitle()), err)
+				return
+			}
+			if ok {
+				swept++
+			}
+		}
+	}
+	log.Printf("🔁 milestone %s closed, swept %d cards of %d open pull requests\n", e.GetMilestone().GetTitle(), swept, len(prs))
+	w.WriteHeader(http.StatusOK)
+}
+
+// sweepMilestoneCard moves or archives the card of the pull request on the board, and returns true if it did.
+func (s *server) sweepMilestoneCard(ctx context.Context, b *board, pr *github.PullRequest) (bool, error) {
+	if s.cfg.milestoneClosedTarget != archiveTarget {
+		_, o, err := s.placeCard(ctx, b, pr, placement{column: s.cfg.milestoneClosedTarget})
+		return o == moved, err
+	}
+	card, _, err := s.findCard(ctx, b.inSet(s.cfg.columnSet(pr)), pr)
+	if err != nil || card == nil {
+		return false, err
+	}
+	archived := true
+	if _, _, err := s.client.Projects.UpdateProjectCard(ctx, card.GetID(), &github.ProjectCardOptions{Archived: &archived}); err != nil {
+		return false, fmt.Errorf("archive project card for pr %s: %w", pr.GetTitle(), err)
+	}
+	log.Printf("🏗️ archived card for pr %s in project %s\n", pr.GetTitle(), b.project.GetName())
+	return true, nil
+}
+
+// listMilestonePullRequests returns the open pull requests of the milestone.
+func listMilestonePullRequests(ctx context.Context, client *github.Client, repo *github.Repository, m *github.Milestone) ([]*github.PullRequest, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	opts := &github.IssueListByRepoOptions{
+		Milestone:   strconv.Itoa(m.GetNumber()),
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var prs []*github.PullRequest
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, owner, name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list issues of milestone %d: %w", m.GetNumber(), err)
+		}
+		for _, issue := range issues {
+			if !issue.IsPullRequest() {
+				continue
+			}
+			pr, _, err := client.PullRequests.Get(ctx, owner, name, issue.GetNumber())
+			if err != nil {
+				return nil, fmt.Errorf("get pr %d: %w", issue.GetNumber(), err)
+			}
+			prs = append(prs, pr)
+		}
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
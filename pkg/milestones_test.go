@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func milestoneEvent(action string, m *github.Milestone, repo *github.Repository) *github.MilestoneEvent {
+	return &github.MilestoneEvent{
+		Action:    github.String(action),
+		Milestone: m,
+		Repo:      repo,
+		Sender:    &github.User{Login: github.String("octocat")},
+	}
+}
+
+// Closing a milestone sweeps the cards of its open pull requests to the configured target, only when configured.
+func TestMilestoneClosed(t *testing.T) {
+	sprint := &github.Milestone{Number: github.Int(3), Title: github.String("Sprint 3")}
+	for _, target := range []string{"", BACKLOG, "archive"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"MILESTONE_CLOSED_TARGET": target, "EVENT_TYPES": "pull_request,milestone"})
+		var inSprint []*github.PullRequest
+		// The pull requests of the milestone span several pages.
+		for n := 1; n <= 105; n++ {
+			pr := f.newPR(n)
+			if n%50 == 0 {
+				pr.Milestone = sprint
+				inSprint = append(inSprint, pr)
+			}
+			f.addPR(pr)
+		}
+		for _, pr := range inSprint {
+			f.addCard(f.column(IN_PROGRESS), pr)
+		}
+		other := f.pr(1)
+		f.addCard(f.column(IN_PROGRESS), other)
+
+		rec := sendWebhook(t, s, "milestone", milestoneEvent("closed", sprint, other.GetBase().GetRepo()))
+		want := http.StatusOK
+		if target == "" {
+			want = http.StatusAccepted
+		}
+		if rec.Code != want {
+			t.Errorf("MILESTONE_CLOSED_TARGET=%q: got status %d, want %d: %s", target, rec.Code, want, rec.Body.String())
+		}
+		for _, pr := range inSprint {
+			card := f.cardOf(pr)
+			switch {
+			case target == "" && card.column != f.column(IN_PROGRESS):
+				t.Errorf("unconfigured: got card of pr %d moved, want it left", pr.GetNumber())
+			case target == BACKLOG && card.column != f.column(BACKLOG):
+				t.Errorf("%s: got card of pr %d in column %d, want it swept", target, pr.GetNumber(), card.column)
+			case target == "archive" && !card.Archived:
+				t.Errorf("%s: got card of pr %d left, want it archived", target, pr.GetNumber())
+			}
+		}
+		if card := f.cardOf(other); card.column != f.column(IN_PROGRESS) || card.Archived {
+			t.Errorf("MILESTONE_CLOSED_TARGET=%q: got the card of a pull request out of the milestone swept", target)
+		}
+		f.close()
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// defaultNoteTemplate is the body of note cards when no template is configured.
+// The link lets the bot find the card again, and GitHub renders it as a preview of the pull request.
+const defaultNoteTemplate = "{{.Title}}\n{{.URL}}"
+
+// noteData is what note card templates can refer to.
+type noteData struct {
+	Title  string
+	Number int
+	Author string
+	URL    string
+	Labels []string
+}
+
+// renderNote returns the body of the note card standing for the pull request.
+func renderNote(tmpl *template.Template, pr *github.PullRequest) (string, error) {
+	data := noteData{
+		Title:  pr.GetTitle(),
+		Number: pr.GetNumber(),
+		Author: pr.GetUser().GetLogin(),
+		URL:    pr.GetHTMLURL(),
+	}
+	for _, label := range pr.Labels {
+		data.Labels = append(data.Labels, label.GetName())
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// isNoteOf returns true if the card is a note card standing for the pull request.
+func isNoteOf(card *github.ProjectCard, pr *github.PullRequest) bool {
+	return card.GetNote() != "" && pr.GetHTMLURL() != "" && strings.Contains(card.GetNote(), pr.GetHTMLURL())
+}
+
+// syncNotes renders the note cards of the pull request again on each board, so that they show its current labels.
+// Cards linked to the pull request already show its labels and are left alone.
+func (s *server) syncNotes(ctx context.Context, pr *github.PullRequest) error {
+	boards, err := s.repoBoards(ctx, pr.GetBase().GetRepo())
+	if err != nil {
+		return err
+	}
+	note, err := renderNote(s.cfg.noteTemplate, pr)
+	if err != nil {
+		return fmt.Errorf("render note card for pr %s: %w", pr.GetTitle(), err)
+	}
+	for _, b := range boards {
+		card, _, err := s.findCard(ctx, b.inSet(s.cfg.columnSet(pr)), pr)
+		if err != nil {
+			return err
+		}
+		if card == nil || card.GetContentURL() != "" || card.GetNote() == note {
+			continue
+		}
+		if _, _, err := s.client.Projects.UpdateProjectCard(ctx, card.GetID(), &github.ProjectCardOptions{Note: note}); err != nil {
+			return fmt.Errorf("update note card for pr %s: %w", pr.GetTitle(), err)
+		}
+		log.Printf("🏗️ updated note card for pr %s in project %s\n", pr.GetTitle(), b.project.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Note cards are rendered again with the labels of their pull request when SYNC_NOTE_LABELS is set.
+func TestSyncNoteLabels(t *testing.T) {
+	for _, sync := range []string{"false", "true"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{
+			"NOTE_CARD_TEMPLATE": `{{.Title}} [{{range .Labels}}{{.}}{{end}}] {{.URL}}`,
+			"SYNC_NOTE_LABELS":   sync,
+		})
+		pr, other := f.pr(1), f.pr(2)
+		column := f.column(IN_REVIEW)
+		f.mu.Lock()
+		f.nextID++
+		note := &fakeCard{ID: f.nextID, Note: "Feature 1 [] " + pr.GetHTMLURL(), column: column}
+		f.cards = append(f.cards, note)
+		f.mu.Unlock()
+		linked := f.addCard(column, other)
+
+		pr.Labels = []*github.Label{{Name: github.String("bug")}}
+		if rec := sendWebhook(t, s, "pull_request", prEvent("labeled", pr)); rec.Code >= http.StatusBadRequest {
+			t.Fatalf("SYNC_NOTE_LABELS=%s: got status %d: %s", sync, rec.Code, rec.Body.String())
+		}
+		want := "Feature 1 [] " + pr.GetHTMLURL()
+		if sync == "true" {
+			want = "Feature 1 [bug] " + pr.GetHTMLURL()
+		}
+		f.mu.Lock()
+		if note.Note != want {
+			t.Errorf("SYNC_NOTE_LABELS=%s: got note %q, want %q", sync, note.Note, want)
+		}
+		if linked.Note != "" {
+			t.Errorf("SYNC_NOTE_LABELS=%s: got a note %q on the linked card, want it left alone", sync, linked.Note)
+		}
+		f.mu.Unlock()
+		f.close()
+	}
+	if _, err := loadTestConfig(map[string]string{"SYNC_NOTE_LABELS": "true"}); err == nil {
+		t.Error("got no error for SYNC_NOTE_LABELS without a note template")
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// defaultNotifyTemplate is the message posted for card changes when no template is configured.
+// New cards have no column they're moved from.
+const defaultNotifyTemplate = "{{if .from}}Moved #{{.number}} {{.title}} by {{.author}} from {{.from}} to {{.to}}" +
+	"{{else}}Added #{{.number}} {{.title}} by {{.author}} to {{.to}}{{end}}: {{.url}}"
+
+// notifyTimeout bounds how long posting a notification can take.
+const notifyTimeout = 10 * time.Second
+
+// parseNotifyTemplate parses a notification template. Fields are looked up in a map
+// so that unknown fields render empty instead of failing.
+func parseNotifyTemplate(text string) (*template.Template, error) {
+	return template.New("notify").Option("missingkey=zero").Parse(text)
+}
+
+// cardEvent is a card of a pull request added to or moved between columns.
+type cardEvent struct {
+	// fields describe the change: the number, title, author and url of the pull request,
+	// and the titles of the columns the card moved from and to.
+	fields map[string]string
+	// message is the change rendered with the notification template.
+	message string
+}
+
+// notifier is a sink told about card changes.
+type notifier interface {
+	notify(ctx context.Context, e cardEvent) error
+}
+
+// notifyURLVars are the variables of the incoming webhooks of the notifiers posting to one, by name.
+var notifyURLVars = map[string]string{
+	"slack":   "NOTIFY_SLACK_URL",
+	"teams":   "NOTIFY_TEAMS_URL",
+	"webhook": "NOTIFY_WEBHOOK_URL",
+}
+
+// notifiers are the sinks selectable with NOTIFIERS, by name.
+var notifiers = map[string]func(cfg *config) (notifier, error){
+	"log":     func(cfg *config) (notifier, error) { return logNotifier{}, nil },
+	"slack":   newChatNotifier("slack"),
+	"teams":   newChatNotifier("teams"),
+	"webhook": newChatNotifier("webhook"),
+}
+
+// notify tells the notifiers about the card of the pull request moving from a column to another.
+// Each notifier runs on its own, so that a failing or slow sink neither holds up the others nor the webhook.
+func (s *server) notify(pr *github.PullRequest, from, to string) {
+	if len(s.cfg.notifiers) == 0 {
+		return
+	}
+	e := cardEvent{fields: map[string]string{
+		"number": strconv.Itoa(pr.GetNumber()),
+		"title":  pr.GetTitle(),
+		"author": pr.GetUser().GetLogin(),
+		"url":    pr.GetHTMLURL(),
+		"from":   s.cfg.columnTitle(from),
+		"to":     s.cfg.columnTitle(to),
+	}}
+	var msg strings.Builder
+	if err := s.cfg.notifyTemplate.Execute(&msg, e.fields); err != nil {
+		log.Printf("🚨 error rendering notification for pr %s: err=%s\n", pr.GetTitle(), err)
+		return
+	}
+	e.message = msg.String()
+	for _, n := range s.cfg.notifiers {
+		go func(n notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := n.notify(ctx, e); err != nil {
+				log.Printf("🚨 error posting notification for pr %s: err=%s\n", pr.GetTitle(), err)
+			}
+		}(n)
+	}
+}
+
+// logNotifier logs the notifications.
+type logNotifier struct{}
+
+func (logNotifier) notify(ctx context.Context, e cardEvent) error {
+	log.Printf("🔔 %s\n", e.message)
+	return nil
+}
+
+// chatNotifier posts the notifications to an incoming webhook, in its format "slack" or "teams",
+// or as the JSON object of the event fields for a generic "webhook".
+type chatNotifier struct {
+	url    string
+	format string
+}
+
+func newChatNotifier(format string) func(cfg *config) (notifier, error) {
+	return func(cfg *config) (notifier, error) {
+		url := cfg.notifyURLs[format]
+		if url == "" {
+			return nil, fmt.Errorf("%s must be set for the %s notifier", notifyURLVars[format], format)
+		}
+		return chatNotifier{url: url, format: format}, nil
+	}
+}
+
+func (n chatNotifier) notify(ctx context.Context, e cardEvent) error {
+	var payload interface{}
+	switch n.format {
+	case "teams":
+		payload = map[string]string{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"text":     e.message,
+		}
+	case "webhook":
+		fields := map[string]string{"text": e.message}
+		for k, v := range e.fields {
+			fields[k] = v
+		}
+		payload = fields
+	default:
+		payload = map[string]string{"text": e.message}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNotifier records the notifications, and fails with err.
+type fakeNotifier struct {
+	err  error
+	mu   sync.Mutex
+	sent []cardEvent
+}
+
+func (n *fakeNotifier) notify(ctx context.Context, e cardEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, e)
+	return n.err
+}
+
+func (n *fakeNotifier) messages() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var messages []string
+	for _, e := range n.sent {
+		messages = append(messages, e.message)
+	}
+	return messages
+}
+
+// Every notifier is told about card changes, even when another one fails.
+func TestNotifyAllNotifiers(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	failing, ok := &fakeNotifier{err: errors.New("sink is down")}, &fakeNotifier{}
+	s.cfg.notifiers = []notifier{failing, ok}
+	pr := f.pr(1)
+	f.addCard(f.column(IN_PROGRESS), f.pr(2))
+
+	sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+	sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(2)))
+
+	want := []string{
+		"Added #1 Feature 1 by octocat to In review: https://github.com/iamhopaul123/penghaoh-flask-app/pull/1",
+		"Moved #2 Feature 2 by octocat from In progress to In review: https://github.com/iamhopaul123/penghaoh-flask-app/pull/2",
+	}
+	for _, n := range []*fakeNotifier{failing, ok} {
+		if !waitFor(func() bool { return len(n.messages()) == 2 }) {
+			t.Fatalf("got notifications %q, want 2", n.messages())
+		}
+		got := n.messages()
+		// Notifiers run concurrently, so the notifications may be in any order.
+		if !(got[0] == want[0] && got[1] == want[1] || got[0] == want[1] && got[1] == want[0]) {
+			t.Errorf("got notifications %q, want %q", got, want)
+		}
+	}
+}
+
+// Each chat notifier posts to its own incoming webhook.
+func TestNotifierURLs(t *testing.T) {
+	cfg := testConfig(t, map[string]string{
+		"NOTIFIERS":          "log,slack,webhook",
+		"NOTIFY_SLACK_URL":   "https://hooks.slack.example/1",
+		"NOTIFY_WEBHOOK_URL": "https://example.com/hook",
+	})
+	var urls []string
+	for _, n := range cfg.notifiers {
+		if chat, ok := n.(chatNotifier); ok {
+			urls = append(urls, chat.format+"="+chat.url)
+		}
+	}
+	if len(urls) != 2 || urls[0] != "slack=https://hooks.slack.example/1" || urls[1] != "webhook=https://example.com/hook" {
+		t.Errorf("got chat notifiers %q, want slack and webhook with their own URLs", urls)
+	}
+	if _, err := loadTestConfig(map[string]string{"NOTIFIERS": "teams", "NOTIFY_WEBHOOK_URL": "https://example.com/hook"}); err == nil {
+		t.Error("got no error for the teams notifier without NOTIFY_TEAMS_URL")
+	}
+}
+
+// Without NOTIFIERS, NOTIFY_WEBHOOK_URL still gets the notifications in NOTIFY_FORMAT.
+func TestNotifierLegacyURL(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"NOTIFY_WEBHOOK_URL": "https://example.com/hook", "NOTIFY_FORMAT": "teams"})
+	if len(cfg.notifiers) != 1 || cfg.notifiers[0] != (chatNotifier{url: "https://example.com/hook", format: "teams"}) {
+		t.Errorf("got notifiers %+v, want teams posting to NOTIFY_WEBHOOK_URL", cfg.notifiers)
+	}
+}
+
+func TestChatNotifierFormats(t *testing.T) {
+	for format, key := range map[string]string{"slack": "text", "teams": "@type", "webhook": "number"} {
+		var got map[string]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(body, &got)
+		}))
+		n := chatNotifier{url: srv.URL, format: format}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := n.notify(ctx, cardEvent{fields: map[string]string{"number": "1"}, message: "Added #1"})
+		cancel()
+		srv.Close()
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if got["text"] != "Added #1" || got[key] == "" {
+			t.Errorf("%s: got payload %v, want the message and %s", format, got, key)
+		}
+	}
+}
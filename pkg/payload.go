@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Content types GitHub delivers webhooks with.
+const (
+	jsonContentType = "application/json"
+	formContentType = "application/x-www-form-urlencoded"
+)
+
+// errMissingSignature is returned for webhooks without a signature header when a webhook secret is set.
+var errMissingSignature = errors.New("webhook request has no signature header")
+
+// validatePayload verifies the signature of the webhook and returns its JSON payload.
+// When CONTENT_TYPE_FALLBACK is set, a body that doesn't decode as its declared content type,
+// such as after a proxy rewrote the header, is decoded as the other content type GitHub uses.
+func (s *server) validatePayload(req *http.Request) ([]byte, error) {
+	signature := signatureOf(req)
+	if s.cfg.webhookSecret != "" && signature == "" {
+		return nil, errMissingSignature
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.webhookSecret != "" {
+		if err := github.ValidateSignature(signature, body, []byte(s.cfg.webhookSecret)); err != nil {
+			return nil, err
+		}
+	}
+	if !s.cfg.contentTypeFallback {
+		// The signature is verified, so the payload is only decoded.
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return github.ValidatePayload(req, nil)
+	}
+	declared := req.Header.Get("Content-Type")
+	payload, used, err := decodePayload(declared, body)
+	if err != nil {
+		return nil, err
+	}
+	if used != declared {
+		log.Printf("⚠️ webhook %s declared Content-Type %q but was decoded as %s\n", github.WebHookType(req), declared, used)
+	}
+	return payload, nil
+}
+
+// signatureOf returns the signature header of the webhook, preferring the SHA-256 one over the legacy SHA-1 one.
+func signatureOf(req *http.Request) string {
+	if signature := req.Header.Get("X-Hub-Signature-256"); signature != "" {
+		return signature
+	}
+	return req.Header.Get("X-Hub-Signature")
+}
+
+// decodePayload returns the JSON payload of the body along with the content type it was decoded as,
+// trying the declared content type first.
+func decodePayload(contentType string, body []byte) ([]byte, string, error) {
+	order := []string{jsonContentType, formContentType}
+	if contentType == formContentType {
+		order = []string{formContentType, jsonContentType}
+	}
+	for _, ct := range order {
+		switch ct {
+		case jsonContentType:
+			if json.Valid(body) {
+				return body, ct, nil
+			}
+		case formContentType:
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				continue
+			}
+			if payload := []byte(form.Get("payload")); json.Valid(payload) {
+				return payload, ct, nil
+			}
+		}
+	}
+	if contentType != jsonContentType && contentType != formContentType {
+		return nil, "", fmt.Errorf("webhook request has unsupported Content-Type %q and a body that isn't JSON or form encoded", contentType)
+	}
+	return nil, "", errors.New("webhook request body isn't JSON or form encoded")
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestValidatePayloadSignatures(t *testing.T) {
+	const body = `{"zen": "Keep it logically awesome."}`
+	testCases := map[string]struct {
+		headers map[string]string
+		wantErr bool
+	}{
+		"sha256 only": {
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign(sha256.New, body)},
+		},
+		"sha1 only": {
+			headers: map[string]string{"X-Hub-Signature": "sha1=" + sign(sha1.New, body)},
+		},
+		"wrong sha256 with a valid sha1": {
+			headers: map[string]string{
+				"X-Hub-Signature":     "sha1=" + sign(sha1.New, body),
+				"X-Hub-Signature-256": "sha256=" + sign(sha256.New, body+" "),
+			},
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		for _, fallback := range []string{"false", "true"} {
+			f := newFakeGitHub()
+			s := newTestServer(t, f, map[string]string{"CONTENT_TYPE_FALLBACK": fallback})
+			req := httptest.NewRequest(http.MethodPost, "/api/projectbot", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			payload, err := s.validatePayload(req)
+			f.close()
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("%s (fallback %s): got no error", name, fallback)
+				}
+				continue
+			}
+			if err != nil || string(payload) != body {
+				t.Errorf("%s (fallback %s): got payload %q and error %v, want the body", name, fallback, payload, err)
+			}
+		}
+	}
+}
+
+// Webhooks without a signature header are counted apart from ones with a wrong signature, both refused.
+func TestMissingSignatureCountedApart(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	missing, failures := missingSignatures.total(), signatureFailures.total()
+
+	for _, signature := range []string{"", "sha256=" + sign(sha256.New, "{}")} {
+		req := httptest.NewRequest(http.MethodPost, "/api/projectbot", strings.NewReader(`{"zen": "Design for failure."}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature-256", signature)
+		}
+		rec := httptest.NewRecorder()
+		newHandler(s).ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("signature %q: got status %d, want %d", signature, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if got := missingSignatures.total() - missing; got != 1 {
+		t.Errorf("got %v missing signatures counted, want 1", got)
+	}
+	if got := signatureFailures.total() - failures; got != 1 {
+		t.Errorf("got %v signature failures counted, want 1", got)
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	const payload = `{"action":"opened"}`
+	form := url.Values{"payload": {payload}}.Encode()
+	testCases := map[string]struct {
+		contentType string
+		body        string
+		wantUsed    string
+		wantErr     bool
+	}{
+		"json":                       {contentType: jsonContentType, body: payload, wantUsed: jsonContentType},
+		"form":                       {contentType: formContentType, body: form, wantUsed: formContentType},
+		"json declared as form":      {contentType: formContentType, body: payload, wantUsed: jsonContentType},
+		"form declared as json":      {contentType: jsonContentType, body: form, wantUsed: formContentType},
+		"form with unsupported type": {contentType: "text/plain", body: form, wantUsed: formContentType},
+		"neither":                    {contentType: jsonContentType, body: "hello", wantErr: true},
+	}
+	for name, tc := range testCases {
+		got, used, err := decodePayload(tc.contentType, []byte(tc.body))
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: got no error", name)
+			}
+			continue
+		}
+		if err != nil || string(got) != payload || used != tc.wantUsed {
+			t.Errorf("%s: got payload %q decoded as %q and error %v, want %q decoded as %q", name, got, used, err, payload, tc.wantUsed)
+		}
+	}
+}
+
+// Without CONTENT_TYPE_FALLBACK, bodies are only decoded as their declared content type.
+func TestContentTypeFallback(t *testing.T) {
+	const body = `{"zen": "Mind your words, they are important."}`
+	for _, fallback := range []string{"false", "true"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"CONTENT_TYPE_FALLBACK": fallback})
+		req := httptest.NewRequest(http.MethodPost, "/api/projectbot", strings.NewReader(body))
+		req.Header.Set("Content-Type", formContentType)
+		req.Header.Set("X-Hub-Signature-256", "sha256="+sign(sha256.New, body))
+		payload, err := s.validatePayload(req)
+		f.close()
+		if decoded := err == nil && string(payload) == body; decoded != (fallback == "true") {
+			t.Errorf("CONTENT_TYPE_FALLBACK=%s: got payload %q and error %v", fallback, payload, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// poll reconciles the board with the open pull requests of the home repository every interval,
+// for environments that can't receive webhooks.
+func (s *server) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		if s.isPaused() {
+			log.Println("⏸️ bot is paused, skipping poll")
+		} else if err := s.reconcile(ctx, homeRepository()); err != nil {
+			if repo, ok := s.cfg.skipsBoardError(err); ok {
+				s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping poll of %s\n", err, repo)
+			} else {
+				s.report(ctx, "reconciling board", err)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// reconcile places the card of each open pull request of the repository where the webhook events would have put it.
+// The columns are read once, so that only the misplaced cards cost requests beyond listing them.
+func (s *server) reconcile(ctx context.Context, repo *github.Repository) error {
+	prs, err := listOpenPullRequests(ctx, s.client, repo.GetOwner().GetLogin(), repo.GetName())
+	if err != nil {
+		return err
+	}
+	boards, err := s.repoBoards(ctx, repo)
+	if err != nil {
+		return err
+	}
+	ctx = withCardSnapshot(ctx)
+	counts := make(map[outcome]int)
+	for _, pr := range prs {
+		if !s.cfg.isTrackedBranch(pr.GetBase().GetRef()) {
+			continue
+		}
+		for _, b := range boards {
+			_, o, err := s.placeCard(ctx, b, pr, s.polledPlacement(pr))
+			if err != nil {
+				return fmt.Errorf("place card for pr %s on project %s: %w", pr.GetTitle(), b.project.GetName(), err)
+			}
+			counts[o]++
+		}
+	}
+	log.Printf("🔁 reconciled %d open pull requests, created %d, moved %d, unchanged %d and skipped %d cards\n",
+		len(prs), counts[created], counts[moved], counts[unchanged], counts[skipped])
+	if s.cfg.cardMetricsInterval == 0 {
+		return s.countCards(ctx, boards)
+	}
+	return nil
+}
+
+// polledPlacement returns the placement matching the current state of the pull request.
+// Cards only move forward on the board, so that cards moved further by hand stay where they are.
+func (s *server) polledPlacement(pr *github.PullRequest) placement {
+	if pr.GetLocked() && contains(s.cfg.columns, FROZEN) {
+		return placement{column: FROZEN}
+	}
+	if s.cfg.isBlocked(pr) {
+		return placement{column: BLOCKED, create: true}
+	}
+	if s.cfg.moveOnReviewRequested && (len(pr.RequestedReviewers) > 0 || len(pr.RequestedTeams) > 0) {
+		return placement{column: IN_REVIEW, create: true, from: s.cfg.columnsBefore(IN_REVIEW)}
+	}
+	return placement{column: s.cfg.openedColumn(pr), create: true, keep: true}
+}
+
+// listOpenPullRequests returns all the open pull requests of the repository.
+func listOpenPullRequests(ctx context.Context, client *github.Client, owner, repo string) ([]*github.PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var prs []*github.PullRequest
+	for {
+		page, resp, err := client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list open pull requests of %s/%s: %w", owner, repo, err)
+		}
+		prs = append(prs, page...)
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+type cardSnapshotKey struct{}
+
+// cardSnapshot caches the cards of the columns listed while reconciling the board,
+// so that each column is listed once rather than once per pull request.
+type cardSnapshot struct {
+	mu    sync.Mutex
+	cards map[int64][]*github.ProjectCard
+}
+
+// withCardSnapshot returns a context caching the cards of the columns searched with it.
+func withCardSnapshot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cardSnapshotKey{}, &cardSnapshot{cards: make(map[int64][]*github.ProjectCard)})
+}
+
+// resetCardSnapshot forgets the cached cards, after the board changed concurrently.
+func resetCardSnapshot(ctx context.Context) {
+	if snap, ok := ctx.Value(cardSnapshotKey{}).(*cardSnapshot); ok {
+		snap.mu.Lock()
+		defer snap.mu.Unlock()
+		snap.cards = make(map[int64][]*github.ProjectCard)
+	}
+}
+
+// snapshotCards lists the cards of the logical column, from the context's snapshot when there is one.
+// Only the cards that aren't archived are cached, archived ones are looked up rarely.
+func (s *server) snapshotCards(ctx context.Context, b *board, columnName, archivedState string) ([]*github.ProjectCard, error) {
+	snap, ok := ctx.Value(cardSnapshotKey{}).(*cardSnapshot)
+	if !ok || archivedState != "not_archived" {
+		return s.listColumnCards(ctx, b, columnName, archivedState)
+	}
+	id := b.columns[columnName].GetID()
+	snap.mu.Lock()
+	defer snap.mu.Unlock()
+	if cards, ok := snap.cards[id]; ok {
+		return cards, nil
+	}
+	cards, err := s.listColumnCards(ctx, b, columnName, archivedState)
+	if err != nil {
+		return nil, err
+	}
+	snap.cards[id] = cards
+	return cards, nil
+}
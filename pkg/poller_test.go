@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logBuffer captures the log, which the goroutines of other tests may still write to.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Reconciling a board that is already correct lists each column once and changes nothing.
+// The cards are counted apart, so that the listings are only those of the reconciliation.
+func TestReconcileCorrectBoard(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"CARD_METRICS_INTERVAL": "1h"})
+	column := f.column(IN_REVIEW)
+	for n := 1; n <= 3; n++ {
+		f.addCard(column, f.pr(n))
+	}
+	f.resetRequests()
+
+	if err := s.reconcile(context.Background(), homeRepository()); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if m := f.mutations(); len(m) != 0 {
+		t.Errorf("got requests %v, want none changing the board", m)
+	}
+	listed := make(map[string]int)
+	for _, r := range f.requests() {
+		if strings.HasSuffix(r.path, "/cards") {
+			listed[r.path]++
+		}
+	}
+	for path, n := range listed {
+		if n > 1 {
+			t.Errorf("%s: listed %d times, want once", path, n)
+		}
+	}
+}
+
+// Reconciling reports how many cards were created, moved, unchanged and skipped,
+// cards that are already placed being kept where they are.
+func TestReconcileCounts(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	f.addCard(f.column(IN_REVIEW), f.pr(1))
+	f.pr(2)
+	var out logBuffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	if err := s.reconcile(context.Background(), homeRepository()); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if want := "reconciled 2 open pull requests, created 1, moved 0, unchanged 0 and skipped 1 cards"; !strings.Contains(out.String(), want) {
+		t.Errorf("got log %q, want it to contain %q", out.String(), want)
+	}
+	if card := f.cardOf(f.newPR(2)); card == nil || card.column != f.column(IN_REVIEW) {
+		t.Errorf("got card %v, want the missing card created in %s", card, IN_REVIEW)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// priorityRank returns the rank of the labels in the priority ordering, lower ranks coming first.
+// Labels without a priority rank after all the prioritized ones.
+func priorityRank(order []string, labels []string) int {
+	rank := len(order)
+	for _, label := range labels {
+		for i, priority := range order {
+			if i < rank && strings.EqualFold(label, priority) {
+				rank = i
+			}
+		}
+	}
+	return rank
+}
+
+// rankedCard is a card of a column along with its priority rank.
+type rankedCard struct {
+	id   int64
+	rank int
+}
+
+// positionAfter returns the position of a card of the rank in a column holding the cards, top first:
+// right after the last card of equal or higher priority, or at the top if there's none.
+func positionAfter(rank int, cards []rankedCard) string {
+	var after int64
+	for _, card := range cards {
+		if card.rank <= rank {
+			after = card.id
+		}
+	}
+	if after == 0 {
+		return "top"
+	}
+	return fmt.Sprintf("after:%d", after)
+}
+
+// priorityPosition returns where the card of the pull request goes in the column according to its priority,
+// which requires looking up the labels of the other cards in the column.
+func (s *server) priorityPosition(ctx context.Context, b *board, card *github.ProjectCard, pr *github.PullRequest, column string) (string, error) {
+	cards, err := s.listCards(ctx, b, column)
+	if err != nil {
+		return "", err
+	}
+	var ranked []rankedCard
+	for _, other := range cards {
+		if other.GetID() == card.GetID() {
+			continue
+		}
+		rank := len(s.cfg.priorityLabels)
+		if url := other.GetContentURL(); url != "" {
+			labels, err := s.contentLabels(ctx, url)
+			if err != nil {
+				return "", err
+			}
+			rank = priorityRank(s.cfg.priorityLabels, labels)
+		}
+		ranked = append(ranked, rankedCard{id: other.GetID(), rank: rank})
+	}
+	var labels []string
+	for _, label := range pr.Labels {
+		labels = append(labels, label.GetName())
+	}
+	return positionAfter(priorityRank(s.cfg.priorityLabels, labels), ranked), nil
+}
+
+// contentLabels returns the names of the labels of the issue or pull request a card links to.
+func (s *server) contentLabels(ctx context.Context, contentURL string) ([]string, error) {
+	req, err := s.client.NewRequest(http.MethodGet, contentURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	var issue github.Issue
+	if _, err := s.client.Do(ctx, req, &issue); err != nil {
+		return nil, fmt.Errorf("get labels of %s: %w", contentURL, err)
+	}
+	var labels []string
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	return labels, nil
+}
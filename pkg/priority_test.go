@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestPriorityRank(t *testing.T) {
+	order := []string{"P0", "P1", "P2"}
+	testCases := map[string]struct {
+		labels []string
+		want   int
+	}{
+		"highest label":    {labels: []string{"P1"}, want: 1},
+		"any case":         {labels: []string{"p0"}, want: 0},
+		"highest wins":     {labels: []string{"P2", "bug", "P0", "P1"}, want: 0},
+		"other labels":     {labels: []string{"bug", "docs"}, want: len(order)},
+		"unlabeled":        {labels: nil, want: len(order)},
+		"last prioritized": {labels: []string{"P2"}, want: 2},
+	}
+	for name, tc := range testCases {
+		if got := priorityRank(order, tc.labels); got != tc.want {
+			t.Errorf("%s: got rank %d, want %d", name, got, tc.want)
+		}
+	}
+}
+
+func TestPositionAfter(t *testing.T) {
+	cards := []rankedCard{{id: 1, rank: 0}, {id: 2, rank: 1}, {id: 3, rank: 1}, {id: 4, rank: 3}}
+	testCases := map[string]struct {
+		rank  int
+		cards []rankedCard
+		want  string
+	}{
+		"empty column":        {rank: 1, cards: nil, want: "top"},
+		"outranks every card": {rank: 0, cards: cards[1:], want: "top"},
+		"after equal rank":    {rank: 1, cards: cards, want: "after:3"},
+		"after higher rank":   {rank: 2, cards: cards, want: "after:3"},
+		"unlabeled last":      {rank: 3, cards: cards, want: "after:4"},
+	}
+	for name, tc := range testCases {
+		if got := positionAfter(tc.rank, tc.cards); got != tc.want {
+			t.Errorf("%s: got position %s, want %s", name, got, tc.want)
+		}
+	}
+}
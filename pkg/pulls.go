@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// mergeableState returns the mergeable state of the pull request, such as "clean" or "dirty".
+// GitHub computes it in the background after each push, so the pull request is fetched again
+// with an exponential backoff until the state is known or the retries run out.
+func mergeableState(ctx context.Context, client *github.Client, cfg *config, repo *github.Repository, pr *github.PullRequest) (string, error) {
+	state := pr.GetMergeableState()
+	backoff := cfg.mergeableBackoff
+	for i := 0; isUnknownMergeableState(state) && i < cfg.mergeableRetries; i++ {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		fetched, _, err := client.PullRequests.Get(ctx, repo.GetOwner().GetLogin(), repo.GetName(), pr.GetNumber())
+		if err != nil {
+			return "", fmt.Errorf("get pr %d: %w", pr.GetNumber(), err)
+		}
+		state = fetched.GetMergeableState()
+	}
+	if isUnknownMergeableState(state) {
+		log.Printf("🤷‍♀️ mergeable state of pr %s is still unknown after %d retries\n", pr.GetTitle(), cfg.mergeableRetries)
+	}
+	return state, nil
+}
+
+func isUnknownMergeableState(state string) bool {
+	return state == "" || state == "unknown"
+}
+
+// changedBase returns the previous base branch of an edited pull request event's payload,
+// if the base branch changed.
+func changedBase(payload []byte) (string, bool) {
+	var event struct {
+		Changes struct {
+			Base *struct {
+				Ref struct {
+					From string `json:"from"`
+				} `json:"ref"`
+			} `json:"base"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.Changes.Base == nil {
+		return "", false
+	}
+	return event.Changes.Base.Ref.From, true
+}
+
+// maxLoggedPayload is how much of a malformed payload is logged.
+const maxLoggedPayload = 512
+
+// isFork returns true if the head branch of the pull request is in a fork of the repository.
+// The head repository is unknown when the fork was deleted.
+func isFork(pr *github.PullRequest) bool {
+	head := pr.GetHead().GetRepo()
+	if head == nil {
+		return false
+	}
+	if head.GetFork() {
+		return true
+	}
+	owner := pr.GetBase().GetRepo().GetOwner().GetLogin()
+	return owner != "" && !strings.EqualFold(head.GetOwner().GetLogin(), owner)
+}
+
+// isMissingPullRequest returns true if a partial or malformed payload didn't carry the pull request of the event.
+func isMissingPullRequest(pr *github.PullRequest) bool {
+	return pr == nil || pr.GetID() == 0 && pr.GetNodeID() == ""
+}
+
+// truncatePayload returns the start of the payload, for logging.
+func truncatePayload(payload []byte) string {
+	if len(payload) > maxLoggedPayload {
+		return string(payload[:maxLoggedPayload]) + "..."
+	}
+	return string(payload)
+}
+
+// countApprovals returns how many reviewers currently approve the pull request, according to their latest review.
+// Comments don't change a reviewer's approval, while requesting changes or a dismissal withdraws it.
+func countApprovals(ctx context.Context, client *github.Client, repo *github.Repository, pr *github.PullRequest) (int, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	latest := make(map[string]string)
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(ctx, repo.GetOwner().GetLogin(), repo.GetName(), pr.GetNumber(), opts)
+		if err != nil {
+			return 0, fmt.Errorf("list reviews of pr %d: %w", pr.GetNumber(), err)
+		}
+		// Reviews are listed in chronological order.
+		for _, review := range reviews {
+			if state := review.GetState(); state != "COMMENTED" && state != "PENDING" {
+				latest[review.GetUser().GetLogin()] = state
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	approvals := 0
+	for _, state := range latest {
+		if state == "APPROVED" {
+			approvals++
+		}
+	}
+	return approvals, nil
+}
+
+// reviewDecisionQuery gets whether the required reviews of a pull request, such as its code owners', approve it.
+const reviewDecisionQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) { pullRequest(number: $number) { reviewDecision } }
+}`
+
+// reviewDecision returns the review decision of the pull request: APPROVED, CHANGES_REQUESTED or REVIEW_REQUIRED,
+// or "" when the branch protection of its base doesn't require reviews.
+func reviewDecision(ctx context.Context, client *github.Client, repo *github.Repository, pr *github.PullRequest) (string, error) {
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewDecision string `json:"reviewDecision"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	variables := map[string]interface{}{
+		"owner":  repo.GetOwner().GetLogin(),
+		"name":   repo.GetName(),
+		"number": pr.GetNumber(),
+	}
+	if err := graphql(ctx, client, reviewDecisionQuery, variables, &data); err != nil {
+		return "", fmt.Errorf("get review decision of pr %d: %w", pr.GetNumber(), err)
+	}
+	return data.Repository.PullRequest.ReviewDecision, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func TestIsFork(t *testing.T) {
+	testCases := map[string]struct {
+		head *github.Repository
+		want bool
+	}{
+		"same repository":   {head: &github.Repository{Owner: &github.User{Login: github.String(OWNER)}}, want: false},
+		"owner in any case": {head: &github.Repository{Owner: &github.User{Login: github.String("IamHopaul123")}}, want: false},
+		"fork":              {head: &github.Repository{Fork: github.Bool(true), Owner: &github.User{Login: github.String(OWNER)}}, want: true},
+		"other owner":       {head: &github.Repository{Owner: &github.User{Login: github.String("contributor")}}, want: true},
+		"deleted fork":      {head: nil, want: false},
+	}
+	f := newFakeGitHub()
+	defer f.close()
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			pr := f.newPR(1)
+			pr.Head.Repo = tc.head
+			if got := isFork(pr); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Pull requests from forks are opened in FORK_COLUMN, and like the others without it.
+func TestForkColumn(t *testing.T) {
+	for _, column := range []string{"", "External contributions"} {
+		f := newFakeGitHub()
+		if column != "" {
+			f.addColumn(f.projectOf(f.column(IN_REVIEW)), column)
+		}
+		s := newTestServer(t, f, map[string]string{"FORK_COLUMN": column})
+		internal := f.pr(1)
+		fork := f.newPR(2)
+		fork.Head.Repo = &github.Repository{
+			Name:     github.String(REPO),
+			FullName: github.String("contributor/" + REPO),
+			Fork:     github.Bool(true),
+			Owner:    &github.User{Login: github.String("contributor")},
+		}
+		f.addPR(fork)
+
+		for _, pr := range []*github.PullRequest{internal, fork} {
+			if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusCreated {
+				t.Errorf("FORK_COLUMN=%q, pr #%d: got status %d, want %d: %s", column, pr.GetNumber(), rec.Code, http.StatusCreated, rec.Body.String())
+			}
+		}
+		if card := f.cardOf(internal); card == nil || card.column != f.column(IN_REVIEW) {
+			t.Errorf("FORK_COLUMN=%q: got card %v for the internal pull request, want it in %s", column, card, IN_REVIEW)
+		}
+		want := IN_REVIEW
+		if column != "" {
+			want = column
+		}
+		if card := f.cardOf(fork); card == nil || card.column != f.column(want) {
+			t.Errorf("FORK_COLUMN=%q: got card %v for the fork pull request, want it in %s", column, card, want)
+		}
+		f.close()
+	}
+}
+
+// Events whose payload doesn't carry the pull request are refused without calling GitHub.
+func TestMissingPullRequest(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		eventType string
+		payload   string
+	}{
+		{name: "no pull request", eventType: "pull_request", payload: `{"action": "opened"}`},
+		{name: "empty pull request", eventType: "pull_request", payload: `{"action": "opened", "pull_request": {}}`},
+		{name: "review without pull request", eventType: "pull_request_review", payload: `{"action": "submitted", "review": {"state": "approved"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeGitHub()
+			defer f.close()
+			s := newTestServer(t, f, nil)
+
+			if rec := sendWebhook(t, s, tc.eventType, tc.payload); rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+			if requests := f.requests(); len(requests) != 0 {
+				t.Errorf("got requests %v, want none", requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// queuedEvent is a validated and parsed webhook event waiting to be processed.
+type queuedEvent struct {
+	deliveryID string
+	eventType  string
+	// repo is the full name of the repository of the event, empty for events without one.
+	repo    string
+	event   interface{}
+	payload []byte
+	// turn is the place of the event among the events of its pull request.
+	turn *turn
+}
+
+// enqueue acknowledges the event right away and hands it over to the workers.
+// When the queue is full, the delivery is refused with 503 Service Unavailable so that it can be redelivered later.
+func (s *server) enqueue(w http.ResponseWriter, ev queuedEvent) {
+	select {
+	case s.queue <- ev:
+		queuedEvents.set(float64(len(s.queue)))
+		w.WriteHeader(http.StatusOK)
+	default:
+		s.slots.release(ev.repo)
+		// The refused event gives up its turn, the later events of the pull request would wait for it forever.
+		s.sequencer.run(ev.turn, func() {})
+		droppedEvents.inc()
+		log.Printf("⚠️ event queue is full, refusing event %s of delivery %s\n", ev.eventType, ev.deliveryID)
+		http.Error(w, "event queue is full", http.StatusServiceUnavailable)
+	}
+}
+
+// work processes the queued events until the queue is closed.
+// An event whose pull request has earlier events still being processed is processed once they are,
+// so that workers never wait for each other.
+func (s *server) work() {
+	for ev := range s.queue {
+		queuedEvents.set(float64(len(s.queue)))
+		ev := ev
+		s.sequencer.run(ev.turn, func() {
+			defer s.slots.release(ev.repo)
+			// The delivery was already answered, the outcome only shows up in the logs and metrics.
+			s.process(&discardResponseWriter{header: make(http.Header)}, ev)
+		})
+	}
+}
+
+// process dispatches the event to the handler of its type.
+func (s *server) process(w http.ResponseWriter, ev queuedEvent) {
+	ctx := withDelivery(context.Background(), ev.deliveryID)
+	ctx, t := s.startTrace(ctx, "webhook "+ev.eventType, map[string]string{
+		"github.event":    ev.eventType,
+		"github.delivery": ev.deliveryID,
+	})
+	defer s.finish(t)
+	if timeout := s.cfg.eventTimeout(ev.eventType); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if s.cfg.debugHeaders {
+		dw := newDebugResponseWriter(w)
+		ctx = withCallCounter(ctx, &dw.calls)
+		w = dw
+	}
+
+	switch e := ev.event.(type) {
+	case *github.PullRequestEvent:
+		s.handlePullRequest(ctx, w, e, ev.payload)
+	case *github.PullRequestReviewEvent:
+		s.handlePullRequestReview(ctx, w, e, ev.payload)
+	case *github.DeploymentStatusEvent:
+		s.handleDeploymentStatus(ctx, w, e)
+	case *github.IssueCommentEvent:
+		s.handleIssueComment(ctx, w, e)
+	case *github.MilestoneEvent:
+		s.handleMilestone(ctx, w, e)
+	case *github.ProjectCardEvent:
+		s.handleProjectCard(ctx, w, e)
+	case *github.IssuesEvent:
+		s.handleIssues(ctx, w, e)
+	case *github.CheckRunEvent:
+		s.handleCheckRun(ctx, w, e)
+	case *github.RepositoryDispatchEvent:
+		s.handleRepositoryDispatch(ctx, w, e)
+	case *pendingPlacement:
+		s.applyPlacement(ctx, w, e.pr, e.p)
+	default:
+		log.Printf("🤷‍♀️ event type %s\n", ev.eventType)
+	}
+}
+
+// discardResponseWriter is the response writer of events processed after their delivery was answered.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(status int)      {}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func TestEventTimeout(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"EVENT_TIMEOUT": "10s", "EVENT_TIMEOUTS": "pull_request = 1m, issue_comment=0s"})
+	for eventType, want := range map[string]time.Duration{
+		"pull_request":        time.Minute,
+		"issue_comment":       0,
+		"pull_request_review": 10 * time.Second,
+	} {
+		if got := cfg.eventTimeout(eventType); got != want {
+			t.Errorf("%s: got timeout %s, want %s", eventType, got, want)
+		}
+	}
+	for _, v := range []string{"pull_request", "pull_request=soon", "pull_request=-1s"} {
+		if _, err := loadTestConfig(map[string]string{"EVENT_TIMEOUTS": v}); err == nil {
+			t.Errorf("EVENT_TIMEOUTS=%s: got no error", v)
+		}
+	}
+}
+
+// Handling an event is cut off after the timeout of its type.
+func TestEventTimeoutApplied(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{
+		"EVENT_TIMEOUT":            "20ms",
+		"EVENT_TIMEOUTS":           "pull_request_review=5s",
+		"MOVE_ON_REVIEW_DISMISSED": "true",
+	})
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		time.Sleep(50 * time.Millisecond)
+		return false
+	})
+	pr := f.pr(1)
+	f.addCard(f.column(PENDING_RELEASE), pr)
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusInternalServerError {
+		t.Errorf("pull_request: got status %d, want %d for the timeout", rec.Code, http.StatusInternalServerError)
+	}
+	if rec := sendWebhook(t, s, "pull_request_review", reviewEvent("dismissed", "dismissed", pr)); rec.Code != http.StatusOK {
+		t.Errorf("pull_request_review: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+// With ASYNC_WORKERS, events are acknowledged right away and processed by the workers.
+func TestAsyncWorkers(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"ASYNC_WORKERS": "1"})
+	pr := f.pr(1)
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !waitFor(func() bool { return f.cardOf(pr) != nil }) {
+		t.Error("got no card, want the event processed by a worker")
+	}
+}
+
+// Deliveries are refused with 503 when the queue is full, giving back their processing slot
+// and their turn among the events of their pull request.
+func TestAsyncQueueFull(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"ASYNC_WORKERS": "1", "ASYNC_QUEUE_SIZE": "1", "MAX_CONCURRENCY": "10"})
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		once.Do(func() {
+			close(blocked)
+			<-unblock
+		})
+		return false
+	})
+	prs := []*github.PullRequest{f.pr(1), f.pr(2), f.pr(3)}
+
+	// The worker is busy with the first event and the second one fills the queue.
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", prs[0])); rec.Code != http.StatusOK {
+		t.Fatalf("first event: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	<-blocked
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", prs[1])); rec.Code != http.StatusOK {
+		t.Fatalf("queued event: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", prs[2])); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("full queue: got status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	s.slots.mu.Lock()
+	held := s.slots.total
+	s.slots.mu.Unlock()
+	if held != 2 {
+		t.Errorf("got %d slots held, want 2 for the processed and the queued events", held)
+	}
+	close(unblock)
+
+	// The refused event gave up its turn, so the redelivery is processed.
+	if !waitFor(func() bool { return f.cardOf(prs[1]) != nil }) {
+		t.Fatal("got no card for the queued event")
+	}
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", prs[2])); rec.Code != http.StatusOK {
+		t.Errorf("redelivery: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !waitFor(func() bool { return f.cardOf(prs[2]) != nil }) {
+		t.Error("got no card for the redelivered event")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// rateLimiter is a token bucket per key, refilled at rate tokens per minute up to rate tokens.
+type rateLimiter struct {
+	rate float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{rate: float64(perMinute), buckets: make(map[string]*bucket)}
+}
+
+// allow takes a token from the bucket of the key. When the bucket is empty, it returns false
+// along with how long until a token is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		// Full buckets are forgotten, so that the buckets of past clients don't pile up.
+		for k, b := range l.buckets {
+			if l.refill(b, now) >= l.rate {
+				delete(l.buckets, k)
+			}
+		}
+		b = &bucket{tokens: l.rate, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.at = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Minute))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *rateLimiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(l.rate, b.tokens+now.Sub(b.at).Minutes()*l.rate)
+}
+
+// rateLimited refuses requests over the global or the per-IP rate limits with 429 Too Many Requests,
+// to protect the bot from floods such as a webhook looping.
+func (s *server) rateLimited(h httprouter.Handle) httprouter.Handle {
+	if s.cfg.webhookRateLimit == 0 && s.cfg.webhookIPRateLimit == 0 {
+		return h
+	}
+	global := newRateLimiter(s.cfg.webhookRateLimit)
+	perIP := newRateLimiter(s.cfg.webhookIPRateLimit)
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		ip := sourceIP(req, s.cfg.trustedProxies)
+		ok, wait := true, time.Duration(0)
+		if s.cfg.webhookIPRateLimit > 0 {
+			ok, wait = perIP.allow(ip)
+		}
+		if ok && s.cfg.webhookRateLimit > 0 {
+			ok, wait = global.allow("")
+		}
+		if !ok {
+			rateLimitedRequests.inc()
+			log.Printf("⚠️ rate limiting webhooks from %s\n", ip)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		h(w, req, ps)
+	}
+}
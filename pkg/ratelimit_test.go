@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestSourceIP(t *testing.T) {
+	for _, c := range []struct {
+		forwarded []string
+		proxies   int
+		want      string
+	}{
+		{nil, 1, "192.0.2.1"},
+		{[]string{"203.0.113.7"}, 1, "203.0.113.7"},
+		// The client can forge the hops before the one the load balancer added.
+		{[]string{"10.0.0.1, 203.0.113.7"}, 1, "203.0.113.7"},
+		{[]string{"10.0.0.1, 203.0.113.7, 198.51.100.2"}, 2, "203.0.113.7"},
+		{[]string{"10.0.0.1, 203.0.113.7", "198.51.100.2"}, 2, "203.0.113.7"},
+		{[]string{"203.0.113.7"}, 3, "203.0.113.7"},
+		{[]string{"203.0.113.7"}, 0, "192.0.2.1"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/projectbot", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		for _, v := range c.forwarded {
+			req.Header.Add("X-Forwarded-For", v)
+		}
+		if got := sourceIP(req, c.proxies); got != c.want {
+			t.Errorf("X-Forwarded-For %q with %d proxies: got %s, want %s", c.forwarded, c.proxies, got, c.want)
+		}
+	}
+}
+
+// Clients behind the load balancer can't dodge the per-IP rate limit by forging X-Forwarded-For.
+func TestRateLimitedForgedForwardedFor(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"WEBHOOK_IP_RATE_LIMIT": "1", "TRUSTED_PROXIES": "1"})
+	h := s.rateLimited(func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})
+	for i, forged := range []string{"10.0.0.1", "10.0.0.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/projectbot", nil)
+		req.Header.Set("X-Forwarded-For", forged+", 203.0.113.7")
+		rec := httptest.NewRecorder()
+		h(rec, req, nil)
+		want := http.StatusOK
+		if i > 0 {
+			want = http.StatusTooManyRequests
+		}
+		if rec.Code != want {
+			t.Errorf("request %d: got status %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
+
+// Without TRUSTED_PROXIES, requests are limited by their peer address whatever their X-Forwarded-For says.
+func TestRateLimitedDirectForwardedFor(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"WEBHOOK_IP_RATE_LIMIT": "1"})
+	h := s.rateLimited(func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})
+	for i, c := range []struct {
+		remote, forged string
+		want           int
+	}{
+		{"192.0.2.1:1234", "203.0.113.7", http.StatusOK},
+		{"192.0.2.1:1234", "203.0.113.8", http.StatusTooManyRequests},
+		{"192.0.2.2:1234", "203.0.113.7", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/projectbot", nil)
+		req.RemoteAddr = c.remote
+		req.Header.Set("X-Forwarded-For", c.forged)
+		rec := httptest.NewRecorder()
+		h(rec, req, nil)
+		if rec.Code != c.want {
+			t.Errorf("request %d from %s forging %s: got status %d, want %d", i+1, c.remote, c.forged, rec.Code, c.want)
+		}
+	}
+}
+
+// Requests over the limits are refused with a Retry-After, per IP and across all IPs.
+func TestRateLimits(t *testing.T) {
+	testCases := map[string]struct {
+		env  map[string]string
+		ips  []string
+		want []int
+	}{
+		"per IP": {
+			env:  map[string]string{"WEBHOOK_IP_RATE_LIMIT": "2"},
+			ips:  []string{"192.0.2.1", "192.0.2.1", "192.0.2.2", "192.0.2.1"},
+			want: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"global": {
+			env:  map[string]string{"WEBHOOK_RATE_LIMIT": "2"},
+			ips:  []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"},
+			want: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"unlimited": {
+			ips:  []string{"192.0.2.1", "192.0.2.1", "192.0.2.1"},
+			want: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+	}
+	for name, tc := range testCases {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, tc.env)
+		h := s.rateLimited(func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+			w.WriteHeader(http.StatusOK)
+		})
+		for i, ip := range tc.ips {
+			req := httptest.NewRequest(http.MethodPost, "/api/projectbot", nil)
+			req.RemoteAddr = ip + ":1234"
+			rec := httptest.NewRecorder()
+			h(rec, req, nil)
+			if rec.Code != tc.want[i] {
+				t.Errorf("%s: request %d from %s: got status %d, want %d", name, i+1, ip, rec.Code, tc.want[i])
+			}
+			if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+				t.Errorf("%s: request %d from %s: got no Retry-After", name, i+1, ip)
+			}
+		}
+		f.close()
+	}
+}
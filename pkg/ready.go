@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/julienschmidt/httprouter"
+)
+
+// readyRetryInterval is how often a failed board check is retried.
+const readyRetryInterval = time.Minute
+
+// readiness is the result of the startup board check.
+type readiness struct {
+	mu sync.Mutex
+	// err is why the board couldn't be resolved, nil once it was.
+	err error
+}
+
+func (r *readiness) set(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *readiness) get() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// errInvalidToken is returned when GitHub rejects GITHUB_TOKEN.
+var errInvalidToken = errors.New("invalid token, check GITHUB_TOKEN")
+
+// checkToken makes a lightweight authenticated call, which doesn't count against the rate limit,
+// so that an invalid token fails the deploy instead of every webhook with 401 Unauthorized.
+func checkToken(ctx context.Context, client *github.Client) error {
+	if repoSecret == "" {
+		return nil
+	}
+	_, _, err := client.RateLimits(ctx)
+	var ge *github.ErrorResponse
+	if errors.As(err, &ge) && ge.Response != nil && ge.Response.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %s", errInvalidToken, ge.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("check token: %w", err)
+	}
+	return nil
+}
+
+// checkBoard resolves the project boards and their columns, and logs their IDs.
+// Configuration problems surface at deploy time instead of on the first webhook.
+func (s *server) checkBoard(ctx context.Context) error {
+	err := checkToken(ctx, s.client)
+	if err == nil {
+		err = s.checkBoards(ctx)
+	}
+	if err != nil {
+		err = s.report(ctx, "checking project board", err)
+		s.ready.set(err)
+		return err
+	}
+	s.ready.set(nil)
+	return nil
+}
+
+// checkBoards resolves the project boards, along with the board of issues when ISSUE_COLUMNS is set,
+// and logs their columns.
+func (s *server) checkBoards(ctx context.Context) error {
+	repo := homeRepository()
+	boards, err := s.repoBoards(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if len(s.cfg.issueColumns) > 0 {
+		issueBoards, err := resolveBoards(ctx, s.client, s.configFor(ctx, repo).forIssues(), repo.GetOwner().GetLogin(), repo.GetName())
+		if err != nil {
+			return fmt.Errorf("issues: %w", err)
+		}
+		boards = append(boards, issueBoards...)
+	}
+	for _, b := range boards {
+		var columns []string
+		for name, column := range b.columns {
+			columns = append(columns, fmt.Sprintf("%s=%d", name, column.GetID()))
+		}
+		sort.Strings(columns)
+		log.Printf("✅ project %s (%d) is ready, columns: %s\n", b.project.GetName(), b.project.GetID(), strings.Join(columns, ", "))
+	}
+	return nil
+}
+
+// retryBoardCheck checks the board again until it's ready, so that fixing the board doesn't need a restart.
+func (s *server) retryBoardCheck() {
+	for {
+		time.Sleep(readyRetryInterval)
+		if s.checkBoard(context.Background()) == nil {
+			return
+		}
+	}
+}
+
+// readyHandler replies 503 Service Unavailable until the board check succeeded.
+func (s *server) readyHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	if err := s.ready.get(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
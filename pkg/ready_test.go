@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// With WAIT_FOR_READY, webhooks arriving before the board check succeeded are refused for GitHub to redeliver.
+func TestWaitForReady(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"WAIT_FOR_READY": "true"})
+	pr := f.pr(1)
+
+	rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("early webhook: got status %d and Retry-After %q, want %d with a Retry-After", rec.Code, rec.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+	if f.cardOf(pr) != nil {
+		t.Error("early webhook: got a card, want the event left for redelivery")
+	}
+
+	if err := s.checkBoard(context.Background()); err != nil {
+		t.Fatalf("check board: %v", err)
+	}
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", pr)); rec.Code != http.StatusCreated {
+		t.Errorf("webhook once ready: got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
+
+// Without WAIT_FOR_READY, webhooks are processed before the board check.
+func TestNoWaitForReady(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.pr(1))); rec.Code != http.StatusCreated {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
+
+// A token GitHub rejects at startup leaves the bot unready with a clear message.
+func TestInvalidTokenAtStartup(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	defer func(secret string) { repoSecret = secret }(repoSecret)
+	repoSecret = "revokedToken"
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return true
+	})
+
+	err := s.checkBoard(context.Background())
+	if !errors.Is(err, errInvalidToken) || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("got error %v, want it to tell the token is invalid", err)
+	}
+	rec := httptest.NewRecorder()
+	s.readyHandler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil), nil)
+	if rec.Code == http.StatusOK || !strings.Contains(rec.Body.String(), "invalid token") {
+		t.Errorf("ready: got status %d and body %q, want unready for the invalid token", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// repoConfigPath is where repositories configure their own project board, when REPO_CONFIG is enabled:
+//
+//	project: Sprint
+//	columns:
+//	  In progress: Doing
+//	  In review: Code review
+//
+// Columns are mapped from the logical column, or its configured title, to the title of the column on the board.
+const repoConfigPath = ".github/projectbot.yml"
+
+// repoConfig is the configuration read from a repository.
+type repoConfig struct {
+	// project is the name of the project board, the global one if empty.
+	project string
+	// columns are the titles of the board columns backing logical columns.
+	columns map[string]string
+}
+
+// parseRepoConfig parses the subset of YAML that in-repo configurations need, and validates the columns.
+func parseRepoConfig(cfg *config, data string) (*repoConfig, error) {
+	rc := &repoConfig{columns: make(map[string]string)}
+	inColumns := false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"key: value\", got %q", n, strings.TrimSpace(line))
+		}
+		key, value := unquoteYAML(parts[0]), unquoteYAML(parts[1])
+		if indented {
+			if !inColumns {
+				return nil, fmt.Errorf("line %d: unexpected indentation", n)
+			}
+			column, ok := cfg.findColumn(key)
+			if !ok {
+				return nil, fmt.Errorf("line %d: columns must only map managed columns, got %q", n, key)
+			}
+			if value == "" {
+				return nil, fmt.Errorf("line %d: column %s must have a title", n, key)
+			}
+			rc.columns[column] = value
+			continue
+		}
+		inColumns = false
+		switch key {
+		case "project":
+			if value == "" {
+				return nil, fmt.Errorf("line %d: project must have a name", n)
+			}
+			rc.project = value
+		case "columns":
+			if value != "" {
+				return nil, fmt.Errorf("line %d: columns must be a mapping", n)
+			}
+			inColumns = true
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q, expected project or columns", n, key)
+		}
+	}
+	return rc, scanner.Err()
+}
+
+// unquoteYAML trims the spaces and the quotes around a YAML scalar.
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// withRepoConfig returns a copy of the configuration with the project board and columns of the repository.
+func (cfg *config) withRepoConfig(rc *repoConfig) *config {
+	c := *cfg
+	if rc.project != "" {
+		c.projectName = rc.project
+		c.projectNumber = 0
+		c.projectID = ""
+	}
+	c.columnTitles = make(map[string]string)
+	for column, title := range cfg.columnTitles {
+		c.columnTitles[column] = title
+	}
+	c.columnPatterns = make(map[string]*regexp.Regexp)
+	for column, re := range cfg.columnPatterns {
+		c.columnPatterns[column] = re
+	}
+	for column, title := range rc.columns {
+		c.columnTitles[column] = title
+		delete(c.columnPatterns, column)
+	}
+	return &c
+}
+
+// repoConfigs caches the configuration of each repository, so that the file isn't fetched on every event.
+type repoConfigs struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]repoConfigEntry
+}
+
+type repoConfigEntry struct {
+	cfg     *config
+	expires time.Time
+}
+
+func newRepoConfigs(ttl time.Duration) *repoConfigs {
+	return &repoConfigs{ttl: ttl, entries: make(map[string]repoConfigEntry)}
+}
+
+func (c *repoConfigs) get(repo string) (*config, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[repo]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.cfg, true
+}
+
+func (c *repoConfigs) put(repo string, cfg *config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[repo] = repoConfigEntry{cfg: cfg, expires: time.Now().Add(c.ttl)}
+}
+
+// configFor returns the configuration for events of the repository.
+// Repositories without a valid configuration file use the global configuration.
+func (s *server) configFor(ctx context.Context, repo *github.Repository) *config {
+	if !s.cfg.repoConfig || repo.GetFullName() == "" {
+		return s.cfg
+	}
+	key := strings.ToLower(repo.GetFullName())
+	if cfg, ok := s.repoConfigs.get(key); ok {
+		return cfg
+	}
+	file, _, _, err := s.client.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), repoConfigPath, nil)
+	var ge *github.ErrorResponse
+	if errors.As(err, &ge) && ge.Response != nil && ge.Response.StatusCode == http.StatusNotFound {
+		s.repoConfigs.put(key, s.cfg)
+		return s.cfg
+	}
+	if err != nil {
+		// Don't cache the fallback, the file is fetched again on the next event.
+		log.Printf("⚠️ could not fetch %s of %s, using the global configuration: err=%s\n", repoConfigPath, repo.GetFullName(), err)
+		return s.cfg
+	}
+	cfg := s.cfg
+	content, err := file.GetContent()
+	if err == nil {
+		var rc *repoConfig
+		if rc, err = parseRepoConfig(s.cfg, content); err == nil {
+			cfg = s.cfg.withRepoConfig(rc)
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️ invalid %s in %s, using the global configuration: err=%s\n", repoConfigPath, repo.GetFullName(), err)
+	}
+	s.repoConfigs.put(key, cfg)
+	return cfg
+}
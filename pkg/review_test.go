@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// reviewEvent returns a pull_request_review event of the action on a review in the state.
+func reviewEvent(action, state string, pr *github.PullRequest) *github.PullRequestReviewEvent {
+	return &github.PullRequestReviewEvent{
+		Action:      github.String(action),
+		Review:      &github.PullRequestReview{State: github.String(state)},
+		PullRequest: pr,
+		Repo:        pr.GetBase().GetRepo(),
+		Sender:      &github.User{Login: github.String("octocat")},
+	}
+}
+
+// Dismissed approvals move the card back to review, only when configured.
+func TestReviewDismissed(t *testing.T) {
+	for _, move := range []string{"false", "true"} {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, map[string]string{"MOVE_ON_REVIEW_DISMISSED": move})
+		pending, progress := f.pr(1), f.pr(2)
+		f.addCard(f.column(PENDING_RELEASE), pending)
+		f.addCard(f.column(IN_PROGRESS), progress)
+
+		sendWebhook(t, s, "pull_request_review", reviewEvent("dismissed", "dismissed", pending))
+		sendWebhook(t, s, "pull_request_review", reviewEvent("dismissed", "dismissed", progress))
+		want := f.column(PENDING_RELEASE)
+		if move == "true" {
+			want = f.column(IN_REVIEW)
+		}
+		if got := f.cardOf(pending).column; got != want {
+			t.Errorf("MOVE_ON_REVIEW_DISMISSED=%s: got card in column %d, want %d", move, got, want)
+		}
+		if got := f.cardOf(progress).column; got != f.column(IN_PROGRESS) {
+			t.Errorf("MOVE_ON_REVIEW_DISMISSED=%s: got card in progress moved to column %d, want it left", move, got)
+		}
+		f.close()
+	}
+}
+
+// Submitted reviews advance the card to the column of their state, and leave it alone when unmapped.
+func TestReviewStateColumns(t *testing.T) {
+	env := map[string]string{"REVIEW_STATE_COLUMNS": "commented=" + IN_REVIEW + ",APPROVED=" + PENDING_RELEASE}
+	testCases := map[string]struct {
+		env   map[string]string
+		state string
+		from  string
+		want  string
+	}{
+		"commented by default":          {state: "commented", from: IN_PROGRESS, want: IN_PROGRESS},
+		"approved by default":           {state: "approved", from: IN_REVIEW, want: IN_REVIEW},
+		"commented":                     {env: env, state: "commented", from: IN_PROGRESS, want: IN_REVIEW},
+		"approved":                      {env: env, state: "approved", from: IN_REVIEW, want: PENDING_RELEASE},
+		"unmapped changes requested":    {env: env, state: "changes_requested", from: IN_REVIEW, want: IN_REVIEW},
+		"commented never moves it back": {env: env, state: "commented", from: PENDING_RELEASE, want: PENDING_RELEASE},
+	}
+	for name, tc := range testCases {
+		f := newFakeGitHub()
+		s := newTestServer(t, f, tc.env)
+		pr := f.pr(1)
+		f.addCard(f.column(tc.from), pr)
+
+		sendWebhook(t, s, "pull_request_review", reviewEvent("submitted", tc.state, pr))
+		if got := f.cardOf(pr).column; got != f.column(tc.want) {
+			t.Errorf("%s: got card in column %d, want %s (%d)", name, got, tc.want, f.column(tc.want))
+		}
+		f.close()
+	}
+	if _, err := loadTestConfig(map[string]string{"REVIEW_STATE_COLUMNS": "dismissed=" + IN_REVIEW}); err == nil {
+		t.Error("got no error for an unknown review state")
+	}
+}
+
+// Approvals only move the card to Pending release once enough reviewers currently approve.
+func TestMinApprovals(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"MIN_APPROVALS": "2", "REVIEW_STATE_COLUMNS": "approved=" + PENDING_RELEASE})
+	pr := f.pr(1)
+	f.addCard(f.column(IN_REVIEW), pr)
+	// The reviews span several pages.
+	for i := 0; i < 120; i++ {
+		f.addReview(pr, "carol", "COMMENTED")
+	}
+	f.addReview(pr, "alice", "APPROVED")
+	f.addReview(pr, "bob", "APPROVED")
+	f.addReview(pr, "bob", "CHANGES_REQUESTED")
+
+	sendWebhook(t, s, "pull_request_review", reviewEvent("submitted", "approved", pr))
+	if got := f.cardOf(pr).column; got != f.column(IN_REVIEW) {
+		t.Errorf("one approval: got card in column %d, want it left in %s", got, IN_REVIEW)
+	}
+
+	f.addReview(pr, "bob", "APPROVED")
+	sendWebhook(t, s, "pull_request_review", reviewEvent("submitted", "approved", pr))
+	if got := f.cardOf(pr).column; got != f.column(PENDING_RELEASE) {
+		t.Errorf("two approvals: got card in column %d, want it moved to %s", got, PENDING_RELEASE)
+	}
+}
+
+// With REQUIRE_REVIEW_DECISION, approvals only move cards once the review decision of the pull request approves it,
+// and pull requests without required reviews fall back to counting approvals.
+func TestRequireReviewDecision(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		decision string
+		want     string
+	}{
+		{name: "approved", decision: "APPROVED", want: PENDING_RELEASE},
+		{name: "review required", decision: "REVIEW_REQUIRED", want: IN_REVIEW},
+		{name: "no required reviews", decision: "", want: PENDING_RELEASE},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeGitHub()
+			defer f.close()
+			s := newTestServer(t, f, map[string]string{
+				"REQUIRE_REVIEW_DECISION": "true",
+				"REVIEW_STATE_COLUMNS":    "approved=" + PENDING_RELEASE,
+			})
+			pr := f.pr(1)
+			f.addCard(f.column(IN_REVIEW), pr)
+			queries := 0
+			f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+				if req.URL.Path != "/graphql" {
+					return false
+				}
+				queries++
+				writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+					"data": map[string]interface{}{
+						"repository": map[string]interface{}{
+							"pullRequest": map[string]interface{}{"reviewDecision": tc.decision},
+						},
+					},
+				})
+				return true
+			})
+
+			sendWebhook(t, s, "pull_request_review", reviewEvent("submitted", "approved", pr))
+
+			if queries != 1 {
+				t.Errorf("got %d review decision queries, want 1", queries)
+			}
+			if got := f.cardOf(pr).column; got != f.column(tc.want) {
+				t.Errorf("got card in column %d, want it in %s", got, tc.want)
+			}
+		})
+	}
+}
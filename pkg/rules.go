@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// routingRule places the card of a pull request in a column when all its conditions hold on the webhook payload.
+// Rules are configured in ROUTING_RULES, separated by semicolons, and the first matching one wins:
+//
+//	ROUTING_RULES='pull_request.user.login == dependabot[bot] => Backlog; pull_request.labels[*].name =~ ^urgent => In review'
+//
+// Conditions are joined with &&, and compare the value at a path of the payload with ==, != or =~ (a regular expression).
+// Paths are keys separated by dots, where [N] picks an element of an array and [*] matches if any element does.
+// Rules are only evaluated for the routedActions, and events matching no rule are placed by the built-in logic.
+// CEL expressions aren't supported: the CEL library isn't a dependency of the bot, and this subset covers
+// the conditions on the payload rules need.
+type routingRule struct {
+	conditions []condition
+	column     string
+	// source is the rule as configured, for logs.
+	source string
+}
+
+type condition struct {
+	path  []pathStep
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// pathStep is a key of an object, or an index of an array when key is empty: -1 for any element.
+type pathStep struct {
+	key   string
+	index int
+}
+
+// routedActions are the pull request actions routing rules place cards for, creating them if needed.
+// Pushes, closed pull requests and reviews keep the built-in logic.
+var routedActions = []string{"opened", "reopened", "edited", "ready_for_review", "labeled", "unlabeled"}
+
+// conditionOps are the comparison operators.
+var conditionOps = []string{"==", "!=", "=~"}
+
+// parseRoutingRules compiles the rules, with their columns resolved to logical columns.
+func parseRoutingRules(cfg *config, v string) ([]routingRule, error) {
+	var rules []routingRule
+	for _, src := range strings.Split(v, ";") {
+		if src = strings.TrimSpace(src); src == "" {
+			continue
+		}
+		parts := strings.SplitN(src, "=>", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("rule %q must be like \"conditions => column\"", src)
+		}
+		column, ok := cfg.findColumn(strings.TrimSpace(parts[1]))
+		if !ok {
+			return nil, fmt.Errorf("rule %q must route to a managed column, got %q", src, strings.TrimSpace(parts[1]))
+		}
+		rule := routingRule{column: column, source: src}
+		for _, expr := range strings.Split(parts[0], "&&") {
+			c, err := parseCondition(strings.TrimSpace(expr))
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", src, err)
+			}
+			rule.conditions = append(rule.conditions, c)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseCondition splits the condition on its first operator, so that values can contain operators.
+func parseCondition(expr string) (condition, error) {
+	i, op := -1, ""
+	for _, o := range conditionOps {
+		if j := strings.Index(expr, o); j >= 0 && (i < 0 || j < i) {
+			i, op = j, o
+		}
+	}
+	if i < 0 {
+		return condition{}, fmt.Errorf("condition %q must compare a path with ==, != or =~", expr)
+	}
+	path, err := parsePath(strings.TrimSpace(expr[:i]))
+	if err != nil {
+		return condition{}, err
+	}
+	c := condition{path: path, op: op, value: unquoteYAML(expr[i+len(op):])}
+	if op == "=~" {
+		if c.re, err = regexp.Compile(c.value); err != nil {
+			return condition{}, fmt.Errorf("condition %q has an invalid regular expression: %w", expr, err)
+		}
+	}
+	return c, nil
+}
+
+func parsePath(s string) ([]pathStep, error) {
+	if s == "" {
+		return nil, fmt.Errorf("condition must start with a path")
+	}
+	var steps []pathStep
+	for _, part := range strings.Split(s, ".") {
+		key := part
+		var indexes []string
+		if i := strings.Index(part, "["); i >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("path %q has an unclosed index", s)
+			}
+			key, indexes = part[:i], strings.Split(part[i+1:len(part)-1], "][")
+		}
+		if key == "" {
+			return nil, fmt.Errorf("path %q has an empty key", s)
+		}
+		steps = append(steps, pathStep{key: key})
+		for _, index := range indexes {
+			if index == "*" {
+				steps = append(steps, pathStep{index: -1})
+				continue
+			}
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("path %q must index arrays with a number or *, got %q", s, index)
+			}
+			steps = append(steps, pathStep{index: n})
+		}
+	}
+	return steps, nil
+}
+
+// matches returns true if all the conditions of the rule hold on the decoded payload.
+func (r routingRule) matches(payload interface{}) bool {
+	for _, c := range r.conditions {
+		if !c.holds(payload) {
+			return false
+		}
+	}
+	return true
+}
+
+// holds evaluates the condition: == and =~ hold if any value at the path matches, != if none equals.
+func (c condition) holds(payload interface{}) bool {
+	values := lookup(payload, c.path)
+	if c.op == "!=" {
+		for _, v := range values {
+			if v == c.value {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if c.op == "==" && v == c.value || c.op == "=~" && c.re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the scalar values at the path of the decoded JSON value, as strings.
+func lookup(v interface{}, path []pathStep) []string {
+	if len(path) == 0 {
+		switch v := v.(type) {
+		case string:
+			return []string{v}
+		case bool:
+			return []string{strconv.FormatBool(v)}
+		case float64:
+			return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+		case nil:
+			return []string{"null"}
+		}
+		return nil
+	}
+	step := path[0]
+	if step.key != "" {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		child, ok := obj[step.key]
+		if !ok {
+			return nil
+		}
+		return lookup(child, path[1:])
+	}
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	if step.index >= 0 {
+		if step.index >= len(arr) {
+			return nil
+		}
+		return lookup(arr[step.index], path[1:])
+	}
+	var values []string
+	for _, elem := range arr {
+		values = append(values, lookup(elem, path[1:])...)
+	}
+	return values
+}
+
+// routedColumn returns the column of the first routing rule matching the payload, if any.
+func (cfg *config) routedColumn(payload []byte) (string, string, bool) {
+	if len(cfg.routingRules) == 0 {
+		return "", "", false
+	}
+	var v interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return "", "", false
+	}
+	for _, r := range cfg.routingRules {
+		if r.matches(v) {
+			return r.column, r.source, true
+		}
+	}
+	return "", "", false
+}
+
+// routed places the card of the pull request in the column of the first routing rule matching the payload
+// of the action, and returns true if a rule matched.
+func (s *server) routed(ctx context.Context, w http.ResponseWriter, action string, pr *github.PullRequest, payload []byte) bool {
+	// Checking the action first spares decoding the payload of frequent events such as pushes.
+	if !contains(routedActions, action) {
+		return false
+	}
+	column, rule, ok := s.cfg.routedColumn(payload)
+	if !ok {
+		return false
+	}
+	log.Printf("🔀 routing rule %q places pr %s in column %s\n", rule, pr.GetTitle(), s.cfg.columnTitle(column))
+	s.applyPlacement(ctx, w, pr, placement{column: column, create: true})
+	return true
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func TestParseRoutingRules(t *testing.T) {
+	cfg := testConfig(t, nil)
+	for _, v := range []string{
+		"pull_request.user.login == octocat",
+		"pull_request.user.login == octocat => Nowhere",
+		"pull_request.user.login => Backlog",
+		"pull_request.title =~ ( => Backlog",
+		"pull_request.labels[x].name == bug => Backlog",
+	} {
+		if _, err := parseRoutingRules(cfg, v); err == nil {
+			t.Errorf("%q: got no error, want one", v)
+		}
+	}
+	rules, err := parseRoutingRules(cfg, "pull_request.user.login == dependabot[bot] => Backlog; pull_request.labels[*].name =~ ^urgent => in review")
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].column != BACKLOG || rules[1].column != IN_REVIEW {
+		t.Errorf("got rules %+v, want rules to %s and %s", rules, BACKLOG, IN_REVIEW)
+	}
+}
+
+func TestRoutedColumn(t *testing.T) {
+	cfg := testConfig(t, map[string]string{
+		"ROUTING_RULES": "pull_request.user.login == dependabot[bot] => Backlog; pull_request.labels[*].name =~ ^urgent && pull_request.draft != true => In progress",
+	})
+	for _, c := range []struct {
+		payload string
+		column  string
+		ok      bool
+	}{
+		{`{"pull_request": {"user": {"login": "dependabot[bot]"}}}`, BACKLOG, true},
+		{`{"pull_request": {"user": {"login": "octocat"}, "draft": false, "labels": [{"name": "bug"}, {"name": "urgent-fix"}]}}`, IN_PROGRESS, true},
+		{`{"pull_request": {"user": {"login": "octocat"}, "draft": true, "labels": [{"name": "urgent"}]}}`, "", false},
+		{`{"pull_request": {"user": {"login": "octocat"}}}`, "", false},
+	} {
+		column, _, ok := cfg.routedColumn([]byte(c.payload))
+		if column != c.column || ok != c.ok {
+			t.Errorf("%s: got %q, %t, want %q, %t", c.payload, column, ok, c.column, c.ok)
+		}
+	}
+}
+
+// Routing rules place cards for the actions they route, and leave the others to the built-in logic.
+func TestRoutingRulesActions(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"ROUTING_RULES": "pull_request.user.login == octocat => Backlog"})
+
+	opened := f.pr(1)
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", opened)); rec.Code != http.StatusCreated {
+		t.Errorf("opened: got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if cards := f.cardsIn(f.column(BACKLOG)); len(cards) != 1 {
+		t.Errorf("opened: got %d cards in %s, want 1", len(cards), BACKLOG)
+	}
+
+	f.resetRequests()
+	pushed := f.pr(2)
+	if rec := sendWebhook(t, s, "pull_request", prEvent("synchronize", pushed)); rec.Code != http.StatusAccepted {
+		t.Errorf("synchronize: got status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	reviewed := &github.PullRequestReviewEvent{
+		Action:      github.String("submitted"),
+		Review:      &github.PullRequestReview{State: github.String("commented")},
+		PullRequest: f.pr(3),
+		Repo:        opened.GetBase().GetRepo(),
+	}
+	sendWebhook(t, s, "pull_request_review", reviewed)
+	if reqs := f.mutations(); len(reqs) > 0 {
+		t.Errorf("got changes %v for unrouted actions, want none", reqs)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/julienschmidt/httprouter"
+)
+
+// selfTestStep is the outcome of a step of the self-test.
+type selfTestStep struct {
+	Step  string `json:"step"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// selfTestHandler exercises write access to the project board of the home repository: it adds a throwaway note card to
+// the scratch column, moves it, archives it and deletes it, and replies with the outcome of each step.
+// Each run cleans up after itself, so it's safe to run repeatedly.
+func (s *server) selfTestHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	ctx := context.Background()
+	var steps []selfTestStep
+	var failure error
+	step := func(name string, do func() error) bool {
+		if failure != nil {
+			return false
+		}
+		if err := do(); err != nil {
+			failure = s.report(ctx, fmt.Sprintf("self-test step %s", name), err)
+			steps = append(steps, selfTestStep{Step: name, Error: failure.Error()})
+			return false
+		}
+		steps = append(steps, selfTestStep{Step: name, OK: true})
+		return true
+	}
+
+	var proj *github.Project
+	var column *github.ProjectColumn
+	var card *github.ProjectCard
+	step("find project", func() error {
+		var err error
+		repo := homeRepository()
+		proj, err = primaryProject(ctx, s.client, s.configFor(ctx, repo), repo.GetOwner().GetLogin(), repo.GetName())
+		return err
+	})
+	step("find scratch column", func() error {
+		columns, err := listProjectColumns(ctx, s.client, proj)
+		if err != nil {
+			return err
+		}
+		for _, c := range columns {
+			if c.GetName() == s.cfg.selfTestColumn {
+				column = c
+				return nil
+			}
+		}
+		return fmt.Errorf("column %s does not exist", s.cfg.selfTestColumn)
+	})
+	step("create card", func() error {
+		var err error
+		card, _, err = s.client.Projects.CreateProjectCard(ctx, column.GetID(), &github.ProjectCardOptions{
+			Note: fmt.Sprintf("project-bot self-test %s", time.Now().UTC().Format(time.RFC3339)),
+		})
+		return err
+	})
+	step("move card", func() error {
+		_, err := s.client.Projects.MoveProjectCard(ctx, card.GetID(), &github.ProjectCardMoveOptions{
+			Position: "bottom",
+			ColumnID: column.GetID(),
+		})
+		return err
+	})
+	archived := true
+	step("archive card", func() error {
+		_, _, err := s.client.Projects.UpdateProjectCard(ctx, card.GetID(), &github.ProjectCardOptions{Archived: &archived})
+		return err
+	})
+	// The card is deleted even if a previous step failed, so that failed runs don't leave it behind.
+	if card != nil {
+		failed := failure
+		failure = nil
+		step("delete card", func() error {
+			_, err := s.client.Projects.DeleteProjectCard(ctx, card.GetID())
+			return err
+		})
+		if failed != nil {
+			failure = failed
+		}
+	}
+
+	status := http.StatusOK
+	if failure != nil {
+		status = statusOf(failure)
+	}
+	writeJSON(w, status, map[string]interface{}{"steps": steps})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// prSequencer processes the events of each pull request one at a time, in the order they were received,
+// so that a synchronize processed before its opened event doesn't misplace the card.
+// Events of different pull requests are still processed concurrently.
+type prSequencer struct {
+	mu    sync.Mutex
+	lanes map[string]*prLane
+}
+
+// prLane are the received events of a pull request that weren't processed yet, oldest first.
+type prLane struct {
+	turns   []*turn
+	running bool
+}
+
+// turn is the place of an event in its pull request's lane.
+type turn struct {
+	key  string
+	run  func()
+	done chan struct{}
+}
+
+func newPRSequencer() *prSequencer {
+	return &prSequencer{lanes: make(map[string]*prLane)}
+}
+
+// sequenceKey returns the pull request the event is about, empty for events that aren't about one.
+func sequenceKey(event interface{}) string {
+	e, ok := event.(interface{ GetPullRequest() *github.PullRequest })
+	if !ok || e.GetPullRequest() == nil {
+		return ""
+	}
+	return prKey(e.GetPullRequest())
+}
+
+// reserve takes the next turn in the lane of the key when the event is received.
+func (q *prSequencer) reserve(key string) *turn {
+	t := &turn{key: key, done: make(chan struct{})}
+	if key == "" {
+		return t
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[key]
+	if lane == nil {
+		lane = &prLane{}
+		q.lanes[key] = lane
+	}
+	lane.turns = append(lane.turns, t)
+	return t
+}
+
+// run processes the event with fn once the earlier events of its pull request are processed.
+// It doesn't wait for them: fn runs right away if its turn has come, and otherwise in a new goroutine
+// once the previous event of the pull request is processed, so that no event's delivery waits on the
+// processing of another one. The turn's done channel is closed once fn returned.
+func (q *prSequencer) run(t *turn, fn func()) {
+	if t.key == "" {
+		defer close(t.done)
+		fn()
+		return
+	}
+	q.mu.Lock()
+	t.run = fn
+	lane := q.lanes[t.key]
+	if lane.running || lane.turns[0] != t {
+		q.mu.Unlock()
+		return
+	}
+	lane.running = true
+	q.mu.Unlock()
+	q.execute(t)
+}
+
+// execute runs the turn, at the head of its lane, and then starts the next turn if it's waiting.
+// A panicking turn still ends, so that the later events of the pull request aren't stuck behind it.
+func (q *prSequencer) execute(t *turn) {
+	defer close(t.done)
+	defer q.advance(t)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("🚨 error panic processing event of %s: %v\n%s", t.key, r, debug.Stack())
+		}
+	}()
+	t.run()
+}
+
+// advance removes the finished turn from its lane, and starts the next turn if it was already run.
+func (q *prSequencer) advance(t *turn) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[t.key]
+	lane.turns = lane.turns[1:]
+	lane.running = false
+	if len(lane.turns) == 0 {
+		delete(q.lanes, t.key)
+		return
+	}
+	if next := lane.turns[0]; next.run != nil {
+		lane.running = true
+		go q.execute(next)
+	}
+}
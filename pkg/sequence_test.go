@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// The events of a pull request are processed in the order their turns were reserved.
+func TestSequencerOrder(t *testing.T) {
+	q := newPRSequencer()
+	first, second, third := q.reserve("pr"), q.reserve("pr"), q.reserve("pr")
+	var mu sync.Mutex
+	var order []int
+	record := func(n int) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, n)
+		}
+	}
+
+	q.run(third, record(3))
+	q.run(second, record(2))
+	q.run(first, record(1))
+	<-third.done
+
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("got order %v, want [1 2 3]", order)
+	}
+	if len(q.lanes) != 0 {
+		t.Errorf("got %d lanes left, want none", len(q.lanes))
+	}
+}
+
+// A panicking event doesn't block the later events of its pull request.
+func TestSequencerPanic(t *testing.T) {
+	q := newPRSequencer()
+	first, second := q.reserve("pr"), q.reserve("pr")
+	ran := make(chan struct{})
+
+	q.run(second, func() { close(ran) })
+	q.run(first, func() { panic("boom") })
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("the event after the panicking one was never processed")
+	}
+	<-first.done
+}
+
+// Finishing an event doesn't process the next events of the pull request in the same call,
+// which would delay the reply to the finished event's delivery.
+func TestSequencerRunsNextEventElsewhere(t *testing.T) {
+	q := newPRSequencer()
+	first, second := q.reserve("pr"), q.reserve("pr")
+	release := make(chan struct{})
+	q.run(second, func() { <-release })
+
+	returned := make(chan struct{})
+	go func() {
+		q.run(first, func() {})
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("processing the first event waited for the second one")
+	}
+	close(release)
+	<-second.done
+}
+
+// Events without a pull request are processed right away.
+func TestSequencerUnkeyed(t *testing.T) {
+	q := newPRSequencer()
+	ran := false
+	turn := q.reserve("")
+	q.run(turn, func() { ran = true })
+	if !ran {
+		t.Error("event without a pull request wasn't processed")
+	}
+	<-turn.done
+}
+
+// A push delivered while the opening of its pull request is processed waits for it, so that it moves the new card.
+func TestWebhooksSequencedPerPullRequest(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"SYNCHRONIZE_ACTION": "mergeable", "MERGEABLE_BACKOFF": "1ms"})
+	pr := f.newPR(1)
+	pr.MergeableState = github.String("dirty")
+	f.addPR(pr)
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		once.Do(func() {
+			close(blocked)
+			<-unblock
+		})
+		return false
+	})
+	f.resetRequests()
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		codes[0] = sendWebhook(t, s, "pull_request", prEvent("opened", pr)).Code
+	}()
+	<-blocked
+	before := len(f.requests())
+	go func() {
+		defer wg.Done()
+		codes[1] = sendWebhook(t, s, "pull_request", prEvent("synchronize", pr)).Code
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if n := len(f.requests()) - before; n != 0 {
+		t.Errorf("got %d requests while the opened event was processed, want the push to wait", n)
+	}
+	close(unblock)
+	wg.Wait()
+
+	if codes[0] != http.StatusCreated || codes[1] != http.StatusOK {
+		t.Errorf("got statuses %v, want [%d %d]", codes, http.StatusCreated, http.StatusOK)
+	}
+	if card := f.cardOf(pr); card == nil || card.column != f.column(IN_PROGRESS) {
+		t.Errorf("got card %v, want it created in %s and moved back to %s", card, IN_REVIEW, IN_PROGRESS)
+	}
+}
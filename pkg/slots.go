@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// processingSlots bounds how many events are processed at once overall and for each repository,
+// so that a burst of events in one repository doesn't starve the others.
+// Queued events hold their slot until they're processed.
+type processingSlots struct {
+	// global is how many events can be processed at once, unlimited if zero.
+	global int
+	// perRepo is how many events of a repository can be processed at once, unlimited if zero.
+	perRepo int
+
+	mu    sync.Mutex
+	total int
+	repos map[string]int
+}
+
+func newProcessingSlots(global, perRepo int) *processingSlots {
+	return &processingSlots{global: global, perRepo: perRepo, repos: make(map[string]int)}
+}
+
+// acquire takes a slot for an event of the repository, and returns false if none is left.
+func (p *processingSlots) acquire(repo string) bool {
+	if p.global == 0 && p.perRepo == 0 {
+		return true
+	}
+	repo = strings.ToLower(repo)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.global > 0 && p.total >= p.global || p.perRepo > 0 && p.repos[repo] >= p.perRepo {
+		return false
+	}
+	p.total++
+	p.repos[repo]++
+	return true
+}
+
+// release gives back the slot taken for an event of the repository.
+func (p *processingSlots) release(repo string) {
+	if p.global == 0 && p.perRepo == 0 {
+		return
+	}
+	repo = strings.ToLower(repo)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total--
+	if p.repos[repo]--; p.repos[repo] <= 0 {
+		delete(p.repos, repo)
+	}
+}
+
+// admit takes a processing slot for the event, or refuses its delivery with 429 Too Many Requests
+// so that GitHub redelivers it later.
+func (s *server) admit(w http.ResponseWriter, ev queuedEvent) bool {
+	if s.slots.acquire(ev.repo) {
+		return true
+	}
+	throttledEvents.inc()
+	log.Printf("⚠️ no processing slot left for %s, refusing event %s of delivery %s\n", ev.repo, ev.eventType, ev.deliveryID)
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "too many events in progress", http.StatusTooManyRequests)
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProcessingSlots(t *testing.T) {
+	p := newProcessingSlots(3, 2)
+	for i, want := range []bool{true, true, false} {
+		if got := p.acquire("acme/widgets"); got != want {
+			t.Errorf("acme/widgets slot %d: got %v, want %v", i+1, got, want)
+		}
+	}
+	if !p.acquire("ACME/Gadgets") {
+		t.Error("acme/gadgets: got no slot, want one while acme/widgets is saturated")
+	}
+	if p.acquire("acme/tools") {
+		t.Error("acme/tools: got a slot, want none over the global limit")
+	}
+	p.release("acme/widgets")
+	if !p.acquire("acme/tools") {
+		t.Error("acme/tools: got no slot after a release")
+	}
+}
+
+// A repository using all of its slots doesn't keep the events of other repositories from being processed.
+func TestRepoConcurrency(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"MAX_REPO_CONCURRENCY": "1"})
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	f.setIntercept(func(w http.ResponseWriter, req *http.Request) bool {
+		if strings.HasPrefix(req.URL.Path, "/repos/acme/widgets/") {
+			close(blocked)
+			<-unblock
+		}
+		return false
+	})
+
+	saturated := make(chan int)
+	go func() {
+		saturated <- sendWebhook(t, s, "pull_request", prEvent("opened", f.newRepoPR("acme", "widgets", 1))).Code
+	}()
+	<-blocked
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.newRepoPR("acme", "widgets", 2))); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("saturated repository: got status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec := sendWebhook(t, s, "pull_request", prEvent("opened", f.newRepoPR("acme", "gadgets", 1))); rec.Code == http.StatusTooManyRequests {
+		t.Errorf("other repository: got status %d, want it processed", rec.Code)
+	}
+	close(unblock)
+	if code := <-saturated; code == http.StatusTooManyRequests {
+		t.Errorf("first event: got status %d, want it processed", code)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Actions taken on the cards of stale pull requests.
+const (
+	// staleBacklog moves the card back to BACKLOG.
+	staleBacklog = "backlog"
+	// staleLabel adds the stale label to the pull request.
+	staleLabel = "label"
+)
+
+// minSweepRateLimit is how many GitHub API requests must be left before a sweep,
+// so that the sweep doesn't starve the webhooks.
+const minSweepRateLimit = 500
+
+// sweepStale flags the cards of the pull requests in progress that weren't updated for a while, every interval.
+func (s *server) sweepStale(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		if s.isPaused() {
+			log.Println("⏸️ bot is paused, skipping stale sweep")
+		} else if err := s.sweep(ctx); err != nil {
+			if repo, ok := s.cfg.skipsBoardError(err); ok {
+				s.missingProjects.printf(repo, "🤷‍♀️ %s, skipping stale sweep of %s\n", err, repo)
+			} else {
+				s.report(ctx, "sweeping stale cards", err)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// sweep flags the cards in IN_PROGRESS whose pull request was last updated longer than the staleness threshold ago.
+func (s *server) sweep(ctx context.Context) error {
+	limits, _, err := s.client.RateLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("get rate limits: %w", err)
+	}
+	if remaining := limits.GetCore().Remaining; remaining < minSweepRateLimit {
+		log.Printf("⚠️ only %d GitHub API requests left until %s, skipping stale sweep\n", remaining, limits.GetCore().Reset)
+		return nil
+	}
+	boards, err := s.repoBoards(ctx, homeRepository())
+	if err != nil {
+		return err
+	}
+	flagged := 0
+	for _, b := range boards {
+		for _, lane := range b.withSetsOf(IN_PROGRESS) {
+			cards, err := s.listCards(ctx, lane, IN_PROGRESS)
+			if err != nil {
+				return err
+			}
+			for _, card := range cards {
+				if card.GetContentURL() == "" {
+					continue
+				}
+				pr, err := s.pullRequestOf(ctx, card)
+				if err != nil {
+					return err
+				}
+				if pr == nil || pr.GetState() != "open" || time.Since(pr.GetUpdatedAt()) < s.cfg.staleAfter {
+					continue
+				}
+				// The card is placed in the columns of the pull request's set, which may not be the lane's.
+				ok, err := s.flagStale(ctx, b, pr)
+				if err != nil {
+					return fmt.Errorf("flag stale pr %s: %w", pr.GetTitle(), err)
+				}
+				if ok {
+					flagged++
+				}
+			}
+		}
+	}
+	log.Printf("🔁 swept stale cards, flagged %d\n", flagged)
+	return nil
+}
+
+// flagStale takes the configured action on the stale pull request, and returns true if it changed anything.
+func (s *server) flagStale(ctx context.Context, b *board, pr *github.PullRequest) (bool, error) {
+	switch s.cfg.staleAction {
+	case staleLabel:
+		for _, label := range pr.Labels {
+			if strings.EqualFold(label.GetName(), s.cfg.staleLabel) {
+				return false, nil
+			}
+		}
+		repo := pr.GetBase().GetRepo()
+		if _, _, err := s.client.Issues.AddLabelsToIssue(ctx, repo.GetOwner().GetLogin(), repo.GetName(), pr.GetNumber(), []string{s.cfg.staleLabel}); err != nil {
+			return false, err
+		}
+		log.Printf("🏗️ labeled stale pr %s as %s\n", pr.GetTitle(), s.cfg.staleLabel)
+		return true, nil
+	default:
+		_, o, err := s.placeCard(ctx, b, pr, placement{column: BACKLOG, from: []string{IN_PROGRESS}})
+		return o == moved, err
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// The columns column sets share with the default columns are swept once, and stale cards go back to the backlog.
+func TestSweepSharedColumnOnce(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	f.addColumn(101, "Fixing")
+	s := newTestServer(t, f, map[string]string{
+		"COLUMN_SETS":             "bugs",
+		"COLUMN_SET_BUGS_COLUMNS": "In review=Fixing",
+		"COLUMN_SET_BUGS_LABELS":  "bug",
+		"STALE_AFTER":             "24h",
+	})
+	pr := f.newPR(1)
+	updated := time.Now().Add(-48 * time.Hour)
+	pr.UpdatedAt = &updated
+	f.addPR(pr)
+	f.addCard(f.column(IN_PROGRESS), pr)
+
+	if err := s.sweep(context.Background()); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+
+	if cards := f.cardsIn(f.column(BACKLOG)); len(cards) != 1 {
+		t.Errorf("got %d cards in %s, want 1", len(cards), BACKLOG)
+	}
+	listed := 0
+	path := fmt.Sprintf("/projects/columns/%d/cards", f.column(IN_PROGRESS))
+	for _, r := range f.requests() {
+		if r.method == http.MethodGet && r.path == path {
+			listed++
+		}
+	}
+	// The card is listed once by the sweep, and once more to place it.
+	if listed > 2 {
+		t.Errorf("got %d listings of column %s, want the sweep to list it once", listed, IN_PROGRESS)
+	}
+}
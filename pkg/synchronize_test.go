@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Pushes leave the board alone without calling GitHub by default.
+func TestSynchronizeDefault(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, nil)
+	pr := f.pr(1)
+	f.addCard(f.column(IN_PROGRESS), pr)
+	f.resetRequests()
+
+	if rec := sendWebhook(t, s, "pull_request", prEvent("synchronize", pr)); rec.Code != http.StatusAccepted {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if requests := f.requests(); len(requests) != 0 {
+		t.Errorf("got requests %v, want none", requests)
+	}
+}
+
+func TestSynchronizeAdvance(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"SYNCHRONIZE_ACTION": "advance"})
+	draft := f.newPR(1)
+	draft.Draft = github.Bool(true)
+	f.addPR(draft)
+	ready := f.pr(2)
+	f.addCard(f.column(IN_PROGRESS), draft)
+	f.addCard(f.column(IN_PROGRESS), ready)
+
+	sendWebhook(t, s, "pull_request", prEvent("synchronize", draft))
+	sendWebhook(t, s, "pull_request", prEvent("synchronize", ready))
+	if got := f.cardOf(draft).column; got != f.column(IN_PROGRESS) {
+		t.Errorf("draft: got card in column %d, want it left in %s", got, IN_PROGRESS)
+	}
+	if got := f.cardOf(ready).column; got != f.column(IN_REVIEW) {
+		t.Errorf("ready: got card in column %d, want it advanced to %s", got, IN_REVIEW)
+	}
+}
+
+func TestSynchronizeMergeable(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"SYNCHRONIZE_ACTION": "mergeable", "MERGEABLE_BACKOFF": "1ms"})
+	conflicted := f.newPR(1)
+	conflicted.MergeableState = github.String("dirty")
+	f.addPR(conflicted)
+	clean := f.newPR(2)
+	clean.MergeableState = github.String("clean")
+	f.addPR(clean)
+	f.addCard(f.column(IN_REVIEW), conflicted)
+	f.addCard(f.column(IN_REVIEW), clean)
+
+	sendWebhook(t, s, "pull_request", prEvent("synchronize", conflicted))
+	sendWebhook(t, s, "pull_request", prEvent("synchronize", clean))
+	if got := f.cardOf(conflicted).column; got != f.column(IN_PROGRESS) {
+		t.Errorf("conflicted: got card in column %d, want it moved back to %s", got, IN_PROGRESS)
+	}
+	if got := f.cardOf(clean).column; got != f.column(IN_REVIEW) {
+		t.Errorf("clean: got card in column %d, want it left in %s", got, IN_REVIEW)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OTLP span kinds and status codes.
+const (
+	spanKindServer  = 2
+	spanKindClient  = 3
+	spanStatusError = 2
+)
+
+// exportTimeout bounds how long exporting the spans of a webhook can take.
+const exportTimeout = 10 * time.Second
+
+// span is a timed operation of a trace.
+type span struct {
+	id, parentID string
+	name         string
+	kind         int
+	start, end   time.Time
+	attrs        map[string]string
+	err          error
+}
+
+// trace collects the spans of a webhook: a root span for the webhook and a child span per GitHub request.
+type trace struct {
+	id   string
+	root *span
+
+	mu    sync.Mutex
+	spans []*span
+}
+
+type traceKey struct{}
+
+// startTrace returns a context collecting a trace of the webhook when an OTLP endpoint is configured, nil otherwise.
+func (s *server) startTrace(ctx context.Context, name string, attrs map[string]string) (context.Context, *trace) {
+	if s.cfg.otlpEndpoint == "" {
+		return ctx, nil
+	}
+	t := &trace{id: randomID(16)}
+	t.root = &span{id: randomID(8), name: name, kind: spanKindServer, start: time.Now(), attrs: attrs}
+	t.spans = append(t.spans, t.root)
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// finish ends the root span of the trace and exports the trace in the background.
+func (s *server) finish(t *trace) {
+	if t == nil {
+		return
+	}
+	t.root.end = time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := s.exportTrace(ctx, t); err != nil {
+			log.Printf("🚨 error exporting trace %s: err=%s\n", t.id, err)
+		}
+	}()
+}
+
+// tracingTransport adds a span to the trace of the request's context for each GitHub request.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (tr *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t, ok := req.Context().Value(traceKey{}).(*trace)
+	if !ok {
+		return tr.next.RoundTrip(req)
+	}
+	sp := &span{
+		id:       randomID(8),
+		parentID: t.root.id,
+		name:     req.Method + " " + req.URL.Path,
+		kind:     spanKindClient,
+		start:    time.Now(),
+		attrs:    map[string]string{"http.method": req.Method, "http.url": req.URL.String()},
+	}
+	resp, err := tr.next.RoundTrip(req)
+	sp.end = time.Now()
+	sp.err = err
+	if resp != nil {
+		sp.attrs["http.status_code"] = strconv.Itoa(resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			sp.err = fmt.Errorf("GitHub answered %s", resp.Status)
+		}
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, sp)
+	t.mu.Unlock()
+	return resp, err
+}
+
+// exportTrace posts the spans of the trace to the OTLP/HTTP endpoint, in its JSON encoding.
+func (s *server) exportTrace(ctx context.Context, t *trace) error {
+	t.mu.Lock()
+	var spans []map[string]interface{}
+	for _, sp := range t.spans {
+		var attrs []map[string]interface{}
+		for k, v := range sp.attrs {
+			attrs = append(attrs, otlpAttribute(k, v))
+		}
+		encoded := map[string]interface{}{
+			"traceId":           t.id,
+			"spanId":            sp.id,
+			"name":              sp.name,
+			"kind":              sp.kind,
+			"startTimeUnixNano": strconv.FormatInt(sp.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(sp.end.UnixNano(), 10),
+			"attributes":        attrs,
+		}
+		if sp.parentID != "" {
+			encoded["parentSpanId"] = sp.parentID
+		}
+		if sp.err != nil {
+			encoded["status"] = map[string]interface{}{"code": spanStatusError, "message": sp.err.Error()}
+		}
+		spans = append(spans, encoded)
+	}
+	t.mu.Unlock()
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []interface{}{otlpAttribute("service.name", s.cfg.serviceName)},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "project-bot"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.cfg.otlpEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+func otlpAttribute(key, value string) map[string]interface{} {
+	return map[string]interface{}{"key": key, "value": map[string]string{"stringValue": value}}
+}
+
+// randomID returns n random bytes, hex encoded.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
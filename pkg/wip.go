@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// wipComment is the WIP_LIMIT_ACTION blocking moves into full columns and commenting on the pull request.
+const wipComment = "comment"
+
+// withinLimit returns the column the card of the pull request goes to given the WIP limit of the placement's column,
+// or "" if the move is blocked. When the column is full, the card goes to the overflow column if one is configured,
+// or else stays where it is and the pull request is told why.
+func (s *server) withinLimit(ctx context.Context, b *board, pr *github.PullRequest, column string) (string, error) {
+	limit, ok := s.cfg.wipLimits[column]
+	if !ok {
+		return column, nil
+	}
+	cards, err := s.listCards(ctx, b, column)
+	if err != nil {
+		return "", err
+	}
+	if len(cards) < limit {
+		return column, nil
+	}
+	if s.cfg.wipLimitAction != wipComment && b.columns[s.cfg.wipLimitAction] != nil {
+		log.Printf("⚠️ column %s is at its limit of %d cards, placing card for pr %s in column %s\n",
+			s.cfg.columnTitle(column), limit, pr.GetTitle(), s.cfg.columnTitle(s.cfg.wipLimitAction))
+		return s.cfg.wipLimitAction, nil
+	}
+	log.Printf("⚠️ column %s is at its limit of %d cards, leaving card for pr %s\n", s.cfg.columnTitle(column), limit, pr.GetTitle())
+	repo := pr.GetBase().GetRepo()
+	body := fmt.Sprintf("This pull request wasn't moved to %s, which is at its limit of %d cards.", s.cfg.columnTitle(column), limit)
+	// Every event trying the move again would otherwise repeat the comment.
+	commented, err := hasComment(ctx, s.client, repo, pr.GetNumber(), body)
+	if err != nil || commented {
+		return "", err
+	}
+	if _, _, err := s.client.Issues.CreateComment(ctx, repo.GetOwner().GetLogin(), repo.GetName(), pr.GetNumber(), &github.IssueComment{Body: &body}); err != nil {
+		return "", fmt.Errorf("comment on pr %s: %w", pr.GetTitle(), err)
+	}
+	return "", nil
+}
+
+// hasComment returns true if the pull request of the repository already has a comment with the body.
+func hasComment(ctx context.Context, client *github.Client, repo *github.Repository, number int, body string) (bool, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, repo.GetOwner().GetLogin(), repo.GetName(), number, opts)
+		if err != nil {
+			return false, fmt.Errorf("list comments of pr %d: %w", number, err)
+		}
+		for _, comment := range comments {
+			if comment.GetBody() == body {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
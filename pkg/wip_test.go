@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+)
+
+// Moves into a full column are blocked, and the pull request is told so once.
+func TestWIPLimitCommentsOnce(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"WIP_LIMITS": "In review=1"})
+	f.addCard(f.column(IN_REVIEW), f.pr(1))
+	pr := f.pr(2)
+	f.addCard(f.column(IN_PROGRESS), pr)
+
+	for i := 0; i < 2; i++ {
+		sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+	}
+
+	if cards := f.cardsIn(f.column(IN_REVIEW)); len(cards) != 1 {
+		t.Errorf("got %d cards in %s, want the limit of 1", len(cards), IN_REVIEW)
+	}
+	if comments := f.commentsOn(pr); len(comments) != 1 {
+		t.Errorf("got comments %q, want one", comments)
+	}
+}
+
+// Moves into a column under its limit go ahead.
+func TestWIPLimitUnderLimit(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"WIP_LIMITS": "In review=2"})
+	f.addCard(f.column(IN_REVIEW), f.pr(1))
+	pr := f.pr(2)
+	f.addCard(f.column(IN_PROGRESS), pr)
+
+	sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+
+	if card := f.cardOf(pr); card == nil || card.column != f.column(IN_REVIEW) {
+		t.Errorf("got card %+v, want it in %s", card, IN_REVIEW)
+	}
+	if comments := f.commentsOn(pr); len(comments) != 0 {
+		t.Errorf("got comments %q, want none", comments)
+	}
+}
+
+// With a column as WIP_LIMIT_ACTION, cards overflow into it instead of staying where they are.
+func TestWIPLimitOverflowColumn(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.close()
+	s := newTestServer(t, f, map[string]string{"WIP_LIMITS": "In review=1", "WIP_LIMIT_ACTION": BACKLOG})
+	f.addCard(f.column(IN_REVIEW), f.pr(1))
+	pr := f.pr(2)
+	f.addCard(f.column(IN_PROGRESS), pr)
+
+	sendWebhook(t, s, "pull_request", prEvent("opened", pr))
+
+	if card := f.cardOf(pr); card == nil || card.column != f.column(BACKLOG) {
+		t.Errorf("got card %+v, want it in the overflow column %s", card, BACKLOG)
+	}
+	if cards := f.cardsIn(f.column(IN_REVIEW)); len(cards) != 1 {
+		t.Errorf("got %d cards in %s, want the limit of 1", len(cards), IN_REVIEW)
+	}
+	if comments := f.commentsOn(pr); len(comments) != 0 {
+		t.Errorf("got comments %q, want none", comments)
+	}
+}